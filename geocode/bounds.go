@@ -0,0 +1,71 @@
+package geocode
+
+// stateBounds are rough bounding boxes of the US states and DC, rounded
+// outward to two decimal places. Alaska stops at the antimeridian, leaving
+// out the far end of the Aleutians.
+var stateBounds = map[string]BoundingBox{
+	"AL": {South: 30.14, North: 35.01, West: -88.48, East: -84.88},
+	"AK": {South: 51.21, North: 71.44, West: -179.99, East: -129.97},
+	"AZ": {South: 31.33, North: 37.01, West: -114.82, East: -109.04},
+	"AR": {South: 33.00, North: 36.50, West: -94.62, East: -89.64},
+	"CA": {South: 32.53, North: 42.01, West: -124.41, East: -114.13},
+	"CO": {South: 36.99, North: 41.01, West: -109.06, East: -102.04},
+	"CT": {South: 40.95, North: 42.05, West: -73.73, East: -71.78},
+	"DE": {South: 38.45, North: 39.84, West: -75.79, East: -75.04},
+	"DC": {South: 38.79, North: 39.00, West: -77.12, East: -76.90},
+	"FL": {South: 24.39, North: 31.00, West: -87.64, East: -79.97},
+	"GA": {South: 30.35, North: 35.00, West: -85.61, East: -80.83},
+	"HI": {South: 18.91, North: 28.41, West: -178.34, East: -154.80},
+	"ID": {South: 41.99, North: 49.00, West: -117.25, East: -111.04},
+	"IL": {South: 36.97, North: 42.51, West: -91.52, East: -87.49},
+	"IN": {South: 37.77, North: 41.77, West: -88.10, East: -84.78},
+	"IA": {South: 40.37, North: 43.50, West: -96.64, East: -90.14},
+	"KS": {South: 36.99, North: 40.01, West: -102.06, East: -94.58},
+	"KY": {South: 36.49, North: 39.15, West: -89.58, East: -81.96},
+	"LA": {South: 28.92, North: 33.02, West: -94.05, East: -88.81},
+	"ME": {South: 42.97, North: 47.46, West: -71.09, East: -66.94},
+	"MD": {South: 37.91, North: 39.73, West: -79.49, East: -75.04},
+	"MA": {South: 41.23, North: 42.89, West: -73.51, East: -69.92},
+	"MI": {South: 41.69, North: 48.31, West: -90.42, East: -82.41},
+	"MN": {South: 43.49, North: 49.39, West: -97.24, East: -89.48},
+	"MS": {South: 30.17, North: 35.01, West: -91.66, East: -88.09},
+	"MO": {South: 35.99, North: 40.62, West: -95.78, East: -89.09},
+	"MT": {South: 44.35, North: 49.01, West: -116.05, East: -104.03},
+	"NE": {South: 39.99, North: 43.01, West: -104.06, East: -95.30},
+	"NV": {South: 35.00, North: 42.01, West: -120.01, East: -114.03},
+	"NH": {South: 42.69, North: 45.31, West: -72.56, East: -70.60},
+	"NJ": {South: 38.92, North: 41.36, West: -75.57, East: -73.88},
+	"NM": {South: 31.33, North: 37.00, West: -109.06, East: -103.00},
+	"NY": {South: 40.49, North: 45.02, West: -79.77, East: -71.85},
+	"NC": {South: 33.84, North: 36.59, West: -84.33, East: -75.45},
+	"ND": {South: 45.93, North: 49.01, West: -104.05, East: -96.55},
+	"OH": {South: 38.40, North: 41.98, West: -84.82, East: -80.51},
+	"OK": {South: 33.61, North: 37.01, West: -103.01, East: -94.43},
+	"OR": {South: 41.99, North: 46.30, West: -124.57, East: -116.46},
+	"PA": {South: 39.71, North: 42.27, West: -80.52, East: -74.68},
+	"RI": {South: 41.14, North: 42.02, West: -71.91, East: -71.11},
+	"SC": {South: 32.03, North: 35.22, West: -83.36, East: -78.54},
+	"SD": {South: 42.47, North: 45.95, West: -104.06, East: -96.43},
+	"TN": {South: 34.98, North: 36.68, West: -90.32, East: -81.64},
+	"TX": {South: 25.83, North: 36.51, West: -106.65, East: -93.50},
+	"UT": {South: 36.99, North: 42.01, West: -114.06, East: -109.04},
+	"VT": {South: 42.72, North: 45.02, West: -73.44, East: -71.46},
+	"VA": {South: 36.54, North: 39.47, West: -83.68, East: -75.16},
+	"WA": {South: 45.54, North: 49.01, West: -124.85, East: -116.91},
+	"WV": {South: 37.20, North: 40.64, West: -82.65, East: -77.71},
+	"WI": {South: 42.49, North: 47.31, West: -92.89, East: -86.24},
+	"WY": {South: 40.99, North: 45.01, West: -111.06, East: -104.05},
+}
+
+// StateBounds returns the bounding box of a US state given by name or
+// postal code
+func StateBounds(state string) (BoundingBox, bool) {
+	box, ok := stateBounds[StateCode(state)]
+	return box, ok
+}
+
+// Contains reports whether c is inside the box
+func (b BoundingBox) Contains(c Coordinates) bool {
+	return c.Latitude >= b.South && c.Latitude <= b.North &&
+		c.Longitude >= b.West && c.Longitude <= b.East
+}
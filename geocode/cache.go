@@ -0,0 +1,261 @@
+package geocode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// cacheSchema creates the table that remembers previous geocoding answers.
+// A row with found = 0 is a negative entry recording that the provider had
+// no match for the query. validated = 1 marks answers that were checked
+// against the query's state and city.
+const cacheSchema = `
+	CREATE TABLE IF NOT EXISTS geocode_cache (
+		query TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		found INTEGER NOT NULL,
+		latitude REAL,
+		longitude REAL,
+		answered_by TEXT,
+		response TEXT,
+		fetched_at DATETIME NOT NULL,
+		ttl_seconds INTEGER NOT NULL,
+		validated INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (query, provider)
+	);
+`
+
+// Cache stores geocoding results in a SQLite table so reruns don't have to
+// go back to the network
+type Cache struct {
+	db *sql.DB
+
+	// TTL is how long a found address stays fresh
+	TTL time.Duration
+	// NegativeTTL is how long a "no results" answer stays fresh
+	NegativeTTL time.Duration
+
+	now func() time.Time
+}
+
+// NewCache creates the cache table in db if needed and returns a Cache that
+// keeps found addresses for 180 days and misses for 30 days
+func NewCache(db *sql.DB) (*Cache, error) {
+	if _, err := db.Exec(cacheSchema); err != nil {
+		return nil, fmt.Errorf("failed to create cache table: %w", err)
+	}
+	// Caches made before answers were marked validated lack the column
+	found, err := hasColumn(db, "geocode_cache", "validated")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if _, err := db.Exec("ALTER TABLE geocode_cache ADD COLUMN validated INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return nil, fmt.Errorf("failed to add validated column to cache table: %w", err)
+		}
+	}
+	return &Cache{
+		db:          db,
+		TTL:         180 * 24 * time.Hour,
+		NegativeTTL: 30 * 24 * time.Hour,
+		now:         time.Now,
+	}, nil
+}
+
+// hasColumn reports whether table has the named column
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, fmt.Errorf("failed to read %s columns: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// NormalizeQuery folds case, punctuation and spacing so trivially different
+// spellings of an address share a cache entry
+func NormalizeQuery(query string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, query)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// Lookup returns a fresh cached answer for query, keyed by its normalized
+// free-text form. A coordinate found by any provider is reused, but a miss
+// only counts for the provider that missed, so switching providers retries
+// addresses another one could not find. When validated is set, only answers
+// that were validated count, so turning validation on doesn't reuse
+// unchecked coordinates. The bool reports whether a fresh entry was found;
+// a negative entry comes back with ErrNoResults as the result's Error.
+func (c *Cache) Lookup(query Query, provider string, validated bool) (Result, bool, error) {
+	rows, err := c.db.Query(`
+		SELECT found, latitude, longitude, answered_by, response, fetched_at, ttl_seconds
+		FROM geocode_cache
+		WHERE query = ? AND validated >= ? AND (found = 1 OR (provider = ? AND validated = ?))
+		ORDER BY found DESC, fetched_at DESC
+	`, NormalizeQuery(query.String()), validated, provider, validated)
+	if err != nil {
+		return Result{}, false, fmt.Errorf("failed to query cache: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			found                bool
+			lat, lon             sql.NullFloat64
+			answeredBy, response sql.NullString
+			fetchedAt            string
+			ttlSeconds           int64
+		)
+		if err := rows.Scan(&found, &lat, &lon, &answeredBy, &response, &fetchedAt, &ttlSeconds); err != nil {
+			return Result{}, false, fmt.Errorf("failed to scan cache entry: %w", err)
+		}
+
+		fetched, err := time.Parse(time.RFC3339Nano, fetchedAt)
+		if err != nil {
+			continue
+		}
+		if c.now().After(fetched.Add(time.Duration(ttlSeconds) * time.Second)) {
+			continue
+		}
+
+		if !found {
+			return Result{Error: ErrNoResults, Cached: true}, true, nil
+		}
+		// Rebuild the full result from the stored response when we can
+		if decode, ok := decoders[answeredBy.String]; ok && response.Valid {
+			if result, err := decode([]byte(response.String)); err == nil {
+				result.Cached = true
+				return result, true, nil
+			}
+		}
+		result := Result{
+			Coords: &Coordinates{
+				Latitude:  lat.Float64,
+				Longitude: lon.Float64,
+			},
+			Provider: answeredBy.String,
+			Cached:   true,
+		}
+		if response.Valid {
+			result.Raw = []byte(response.String)
+		}
+		return result, true, nil
+	}
+
+	return Result{}, false, rows.Err()
+}
+
+// Store records the outcome of a geocoding request, and whether it was
+// validated. Found addresses and ErrNoResults misses are cached; any other
+// error is transient and is not.
+func (c *Cache) Store(query Query, provider string, validated bool, result Result, geocodeErr error) error {
+	var (
+		found      bool
+		lat, lon   sql.NullFloat64
+		answeredBy sql.NullString
+		response   sql.NullString
+		ttl        time.Duration
+	)
+	switch {
+	case geocodeErr == nil && result.Coords != nil:
+		found = true
+		lat = sql.NullFloat64{Float64: result.Coords.Latitude, Valid: true}
+		lon = sql.NullFloat64{Float64: result.Coords.Longitude, Valid: true}
+		answeredBy = sql.NullString{String: result.Provider, Valid: result.Provider != ""}
+		response = sql.NullString{String: string(result.Raw), Valid: len(result.Raw) > 0}
+		ttl = c.TTL
+	case errors.Is(geocodeErr, ErrNoResults):
+		ttl = c.NegativeTTL
+	default:
+		return nil
+	}
+
+	_, err := c.db.Exec(`
+		INSERT OR REPLACE INTO geocode_cache
+			(query, provider, found, latitude, longitude, answered_by, response, fetched_at, ttl_seconds, validated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, NormalizeQuery(query.String()), provider, found, lat, lon, answeredBy, response,
+		c.now().UTC().Format(time.RFC3339Nano), int64(ttl/time.Second), validated)
+	if err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
+
+// Wrap returns a geocoder that consults the cache before calling g. The
+// provider name scopes negative entries and should identify g, such as the
+// spec passed to New. When g validates its results, with Validate, only
+// validated answers are taken from the cache.
+func (c *Cache) Wrap(g Geocoder, provider string) Geocoder {
+	return &Cached{
+		Geocoder:  g,
+		Cache:     c,
+		Provider:  provider,
+		Validated: validates(g),
+	}
+}
+
+// validates reports whether g checks its results against the query
+func validates(g Geocoder) bool {
+	switch g := g.(type) {
+	case *Validated:
+		return true
+	case Chain:
+		for _, member := range g {
+			if !validates(member) {
+				return false
+			}
+		}
+		return len(g) > 0
+	}
+	return false
+}
+
+// Cached is a geocoder backed by a Cache
+type Cached struct {
+	Geocoder Geocoder
+	Cache    *Cache
+	Provider string
+	// Validated marks the wrapped geocoder's answers as validated and
+	// skips cached answers that weren't
+	Validated bool
+}
+
+// Geocode answers from the cache when it has a fresh entry and otherwise
+// asks the wrapped geocoder and remembers the outcome. Failures to read or
+// write the cache are logged but not fatal; the request simply goes to the
+// network.
+func (c *Cached) Geocode(ctx context.Context, query Query) (Result, error) {
+	result, ok, err := c.Cache.Lookup(query, c.Provider, c.Validated)
+	if err != nil {
+		log.Printf("⚠ Geocode cache lookup failed: %v", err)
+	} else if ok {
+		return result, result.Error
+	}
+
+	result, err = c.Geocoder.Geocode(ctx, query)
+	if storeErr := c.Cache.Store(query, c.Provider, c.Validated, result, err); storeErr != nil {
+		log.Printf("⚠ %v", storeErr)
+	}
+	return result, err
+}
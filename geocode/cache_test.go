@@ -0,0 +1,208 @@
+package geocode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestCache returns a cache backed by an in-memory database
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	cache, err := NewCache(db)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	return cache
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1189 N Euclid St., Anaheim, CA 92801", "1189 n euclid st anaheim ca 92801"},
+		{"  1189 n euclid st  anaheim ca 92801 ", "1189 n euclid st anaheim ca 92801"},
+		{"Suite #4, 12 Main St", "suite 4 12 main st"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeQuery(tt.input); got != tt.want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCachedGeocoder(t *testing.T) {
+	cache := newTestCache(t)
+	stub := &stubGeocoder{result: Result{
+		Coords:      &Coordinates{Latitude: 38.0293, Longitude: -78.4767},
+		Provider:    "nominatim",
+		Raw:         []byte(`{"lat":"38.0293","lon":"-78.4767","place_rank":30,"display_name":"1, Main Street, Charlottesville"}`),
+		DisplayName: "1, Main Street, Charlottesville",
+		MatchType:   MatchRooftop,
+	}}
+	g := cache.Wrap(stub, "nominatim")
+
+	first, err := g.Geocode(context.Background(), FreeText("1 Main St, Charlottesville, VA"))
+	if err != nil {
+		t.Fatalf("first Geocode() error = %v", err)
+	}
+	if first.Cached {
+		t.Error("first Geocode() came from cache")
+	}
+
+	second, err := g.Geocode(context.Background(), FreeText("1 main st charlottesville va"))
+	if err != nil {
+		t.Fatalf("second Geocode() error = %v", err)
+	}
+	if !second.Cached {
+		t.Error("second Geocode() did not come from cache")
+	}
+	if second.Coords.Latitude != 38.0293 || second.Provider != "nominatim" || string(second.Raw) != string(stub.result.Raw) ||
+		second.DisplayName != stub.result.DisplayName || second.MatchType != MatchRooftop {
+		t.Errorf("second Geocode() = %+v, want cached copy of %+v", second, stub.result)
+	}
+	if stub.calls != 1 {
+		t.Errorf("wrapped geocoder called %d times, want 1", stub.calls)
+	}
+
+	// A found address is reused by any provider
+	other := &stubGeocoder{err: errors.New("should not be called")}
+	if _, err := cache.Wrap(other, "census").Geocode(context.Background(), FreeText("1 Main St, Charlottesville, VA")); err != nil {
+		t.Errorf("Geocode() with other provider error = %v", err)
+	}
+}
+
+func TestCachedGeocoderNegative(t *testing.T) {
+	cache := newTestCache(t)
+	miss := &stubGeocoder{err: ErrNoResults}
+	g := cache.Wrap(miss, "nominatim")
+
+	for i := 0; i < 2; i++ {
+		result, err := g.Geocode(context.Background(), FreeText("nowhere"))
+		if !errors.Is(err, ErrNoResults) {
+			t.Fatalf("Geocode() error = %v, want ErrNoResults", err)
+		}
+		if wantCached := i == 1; result.Cached != wantCached {
+			t.Errorf("call %d Cached = %v, want %v", i+1, result.Cached, wantCached)
+		}
+	}
+	if miss.calls != 1 {
+		t.Errorf("wrapped geocoder called %d times, want 1", miss.calls)
+	}
+
+	// A miss for one provider is not a miss for another
+	hit := &stubGeocoder{result: Result{Coords: &Coordinates{Latitude: 1, Longitude: 2}}}
+	if _, err := cache.Wrap(hit, "census").Geocode(context.Background(), FreeText("nowhere")); err != nil {
+		t.Errorf("Geocode() with other provider error = %v", err)
+	}
+	if hit.calls != 1 {
+		t.Errorf("other provider called %d times, want 1", hit.calls)
+	}
+}
+
+func TestCacheSkipsTransientErrors(t *testing.T) {
+	cache := newTestCache(t)
+	flaky := &stubGeocoder{err: errors.New("HTTP error: 503")}
+	g := cache.Wrap(flaky, "nominatim")
+
+	g.Geocode(context.Background(), FreeText("somewhere"))
+	g.Geocode(context.Background(), FreeText("somewhere"))
+	if flaky.calls != 2 {
+		t.Errorf("wrapped geocoder called %d times, want 2", flaky.calls)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	cache := newTestCache(t)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	result := Result{Coords: &Coordinates{Latitude: 1, Longitude: 2}}
+	if err := cache.Store(FreeText("somewhere"), "nominatim", false, result, nil); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	if _, ok, err := cache.Lookup(FreeText("somewhere"), "nominatim", false); err != nil || !ok {
+		t.Fatalf("Lookup() = %v, %v, want fresh entry", ok, err)
+	}
+
+	now = now.Add(cache.TTL + time.Second)
+	if _, ok, err := cache.Lookup(FreeText("somewhere"), "nominatim", false); err != nil || ok {
+		t.Errorf("Lookup() after TTL = %v, %v, want expired", ok, err)
+	}
+}
+
+func TestCacheValidated(t *testing.T) {
+	cache := newTestCache(t)
+	query := FreeText("1 Main St, Charlottesville, VA")
+	unchecked := &stubGeocoder{result: Result{Coords: &Coordinates{Latitude: 1, Longitude: 2}}}
+	if _, err := cache.Wrap(unchecked, "nominatim").Geocode(context.Background(), query); err != nil {
+		t.Fatal(err)
+	}
+
+	// An answer cached without validation isn't reused by a validating run
+	checked := &stubGeocoder{result: Result{Coords: &Coordinates{Latitude: 38.0293, Longitude: -78.4767}}}
+	g := cache.Wrap(Validate(checked), "nominatim")
+	if _, ok := g.(*Cached); !ok || !g.(*Cached).Validated {
+		t.Fatalf("Wrap(Validate()) = %+v, want a validated cache", g)
+	}
+	for i := 0; i < 2; i++ {
+		result, err := g.Geocode(context.Background(), query)
+		if err != nil || result.Coords.Latitude != 38.0293 {
+			t.Fatalf("validated Geocode() = %+v, %v, want the validated answer", result, err)
+		}
+	}
+	if checked.calls != 1 {
+		t.Errorf("validated geocoder called %d times, want 1", checked.calls)
+	}
+
+	// A validated answer is good enough without validation
+	if result, ok, err := cache.Lookup(query, "census", false); err != nil || !ok || result.Coords.Latitude != 38.0293 {
+		t.Errorf("Lookup() = %+v, %v, %v, want the validated answer", result, ok, err)
+	}
+}
+
+func TestNewCacheAddsValidated(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	// A cache from before answers were marked validated
+	if _, err := db.Exec(`CREATE TABLE geocode_cache (
+		query TEXT NOT NULL, provider TEXT NOT NULL, found INTEGER NOT NULL,
+		latitude REAL, longitude REAL, answered_by TEXT, response TEXT,
+		fetched_at DATETIME NOT NULL, ttl_seconds INTEGER NOT NULL,
+		PRIMARY KEY (query, provider))`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := NewCache(db); err != nil {
+			t.Fatalf("NewCache() error = %v", err)
+		}
+	}
+	if found, err := hasColumn(db, "geocode_cache", "validated"); err != nil || !found {
+		t.Errorf("hasColumn(validated) = %v, %v, want the column added", found, err)
+	}
+
+	db.Close()
+	if _, err := NewCache(db); err == nil {
+		t.Error("NewCache() of a closed database error = nil, want an error")
+	}
+}
@@ -0,0 +1,140 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// censusURL is the US Census Bureau geocoding service
+const censusURL = "https://geocoding.geo.census.gov/geocoder"
+
+// censusResponse represents the JSON response from the Census locations API
+type censusResponse struct {
+	Result struct {
+		AddressMatches []json.RawMessage `json:"addressMatches"`
+	} `json:"result"`
+}
+
+// censusMatch represents one address match from the Census API
+type censusMatch struct {
+	MatchedAddress string `json:"matchedAddress"`
+	Coordinates    struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	} `json:"coordinates"`
+	AddressComponents struct {
+		PreDirection    string `json:"preDirection"`
+		PreType         string `json:"preType"`
+		StreetName      string `json:"streetName"`
+		SuffixType      string `json:"suffixType"`
+		SuffixDirection string `json:"suffixDirection"`
+		City            string `json:"city"`
+		State           string `json:"state"`
+		Zip             string `json:"zip"`
+	} `json:"addressComponents"`
+}
+
+// Census geocodes US street addresses with the Census Bureau geocoder. It
+// matches against TIGER address ranges, which often works for rural
+// addresses that OpenStreetMap lacks.
+type Census struct {
+	*Client
+	Benchmark string
+}
+
+// NewCensus returns a Census geocoder using client, or the public Census
+// Bureau service if client is nil
+func NewCensus(client *Client) *Census {
+	if client == nil {
+		client = NewClient(censusURL)
+	}
+	return &Census{
+		Client:    client,
+		Benchmark: "Public_AR_Current",
+	}
+}
+
+// Geocode queries the Census Bureau API to get GPS coordinates for an
+// address. Structured queries with a street use the field-wise address
+// search first and fall back to the one-line search.
+func (c *Census) Geocode(ctx context.Context, query Query) (Result, error) {
+	return first(c.GeocodeCandidates(ctx, query, 1))
+}
+
+// GeocodeCandidates returns up to limit possible matches for query, best
+// first
+func (c *Census) GeocodeCandidates(ctx context.Context, query Query, limit int) ([]Result, error) {
+	if query.Street != "" {
+		params := url.Values{}
+		params.Set("street", query.Street)
+		params.Set("city", query.City)
+		params.Set("state", query.State)
+		params.Set("zip", query.PostalCode)
+
+		results, err := c.search(ctx, "address", params, limit)
+		if !errors.Is(err, ErrNoResults) {
+			return results, err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("address", query.String())
+	return c.search(ctx, "onelineaddress", params, limit)
+}
+
+// search runs one Census locations request and decodes up to limit matches
+func (c *Census) search(ctx context.Context, searchType string, params url.Values, limit int) ([]Result, error) {
+	params.Set("benchmark", c.Benchmark)
+	params.Set("format", "json")
+
+	var response censusResponse
+	if err := c.GetJSON(ctx, "/locations/"+searchType, params, &response); err != nil {
+		return nil, err
+	}
+	return decodeAll(response.Result.AddressMatches, limit, decodeCensus)
+}
+
+// decodeCensus turns one Census address match into a Result. Census matches
+// are always interpolated along a TIGER street segment.
+func decodeCensus(raw json.RawMessage) (Result, error) {
+	var match censusMatch
+	if err := json.Unmarshal(raw, &match); err != nil {
+		return Result{}, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	components := match.AddressComponents
+	road := strings.Join(strings.Fields(strings.Join([]string{
+		components.PreDirection, components.PreType, components.StreetName,
+		components.SuffixType, components.SuffixDirection,
+	}, " ")), " ")
+
+	// The house number is only reported as part of the matched address
+	var houseNumber string
+	if fields := strings.Fields(match.MatchedAddress); len(fields) > 0 && strings.Trim(fields[0], "0123456789-") == "" {
+		houseNumber = fields[0]
+	}
+
+	// The Census API reports x as longitude and y as latitude
+	return Result{
+		Coords: &Coordinates{
+			Latitude:  match.Coordinates.Y,
+			Longitude: match.Coordinates.X,
+		},
+		Provider:    "census",
+		Raw:         raw,
+		DisplayName: match.MatchedAddress,
+		MatchType:   MatchInterpolated,
+		Address: Address{
+			HouseNumber: houseNumber,
+			Road:        road,
+			City:        components.City,
+			State:       components.State,
+			Postcode:    components.Zip,
+			Country:     "United States",
+		},
+	}, nil
+}
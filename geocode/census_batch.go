@@ -0,0 +1,208 @@
+package geocode
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CensusBatchLimit is the most addresses the Census batch service accepts
+// in one upload
+const CensusBatchLimit = 10000
+
+// censusBatchTimeout bounds one batch upload; the service can take several
+// minutes to answer a full file
+const censusBatchTimeout = 10 * time.Minute
+
+// BatchAddress is one row of a Census batch upload. ID is echoed back in
+// the matching BatchResult, so it should identify the row, such as the
+// shop's database id.
+type BatchAddress struct {
+	ID     string
+	Street string
+	City   string
+	State  string
+	Zip    string
+}
+
+// BatchStatus is the Census match indicator for one batch row
+type BatchStatus string
+
+// Census batch match indicators
+const (
+	// BatchMatch found the address
+	BatchMatch BatchStatus = "Match"
+	// BatchNoMatch did not find the address
+	BatchNoMatch BatchStatus = "No_Match"
+	// BatchTie found more than one equally good candidate and picked none
+	BatchTie BatchStatus = "Tie"
+)
+
+// BatchResult is one row of a Census batch response
+type BatchResult struct {
+	ID           string
+	InputAddress string
+	Status       BatchStatus
+	// Exact is false when the Census had to correct the input to match it
+	Exact          bool
+	MatchedAddress string
+	Coords         *Coordinates
+	// TigerLineID identifies the TIGER street segment the address is on
+	TigerLineID string
+	// Side is L or R for the side of the street segment
+	Side string
+}
+
+// Result converts a matched batch row into a geocoding Result, or returns
+// ErrNoResults as its Error for a no-match or tie
+func (r BatchResult) Result() Result {
+	if r.Status != BatchMatch || r.Coords == nil {
+		return Result{Error: ErrNoResults}
+	}
+	return Result{
+		Coords:      r.Coords,
+		Provider:    "census",
+		DisplayName: r.MatchedAddress,
+		MatchType:   MatchInterpolated,
+	}
+}
+
+// GeocodeBatch geocodes many addresses with the Census batch service. Lists
+// longer than CensusBatchLimit are sent as several uploads. Results come
+// back in the order of addresses; an address missing from the response is
+// left out.
+func (c *Census) GeocodeBatch(ctx context.Context, addresses []BatchAddress) ([]BatchResult, error) {
+	byID := make(map[string]BatchResult, len(addresses))
+	for start := 0; start < len(addresses); start += CensusBatchLimit {
+		end := min(start+CensusBatchLimit, len(addresses))
+		results, err := c.uploadBatch(ctx, addresses[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			byID[result.ID] = result
+		}
+	}
+
+	ordered := make([]BatchResult, 0, len(byID))
+	for _, address := range addresses {
+		if result, ok := byID[address.ID]; ok {
+			ordered = append(ordered, result)
+		}
+	}
+	return ordered, nil
+}
+
+// uploadBatch sends one batch file and parses the response
+func (c *Census) uploadBatch(ctx context.Context, addresses []BatchAddress) ([]BatchResult, error) {
+	payload, contentType, err := c.batchForm(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	err = c.Retry.do(ctx, func() error {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		body, err = c.send(ctx, "POST", c.BaseURL+"/locations/addressbatch", contentType, payload, censusBatchTimeout)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseCensusBatch(bytes.NewReader(body))
+}
+
+// batchForm builds the multipart form holding the address CSV. The Census
+// expects rows of id, street, city, state, zip with no header.
+func (c *Census) batchForm(addresses []BatchAddress) ([]byte, string, error) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+
+	file, err := form.CreateFormFile("addressFile", "addresses.csv")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build batch upload: %w", err)
+	}
+	rows := csv.NewWriter(file)
+	for _, a := range addresses {
+		rows.Write([]string{a.ID, a.Street, a.City, a.State, a.Zip})
+	}
+	rows.Flush()
+	if err := rows.Error(); err != nil {
+		return nil, "", fmt.Errorf("failed to build batch upload: %w", err)
+	}
+
+	form.WriteField("benchmark", c.Benchmark)
+	form.WriteField("returntype", "locations")
+	if err := form.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to build batch upload: %w", err)
+	}
+	return buf.Bytes(), form.FormDataContentType(), nil
+}
+
+// parseCensusBatch reads a Census batch response. Matched rows have eight
+// columns: id, input address, "Match", Exact or Non_Exact, matched address,
+// "longitude,latitude", TIGER line id and side. No_Match and Tie rows stop
+// after the third.
+func parseCensusBatch(r io.Reader) ([]BatchResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var results []BatchResult
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse batch response: %w", err)
+		}
+		if len(record) < 3 {
+			continue
+		}
+
+		result := BatchResult{
+			ID:           strings.TrimSpace(record[0]),
+			InputAddress: strings.TrimSpace(record[1]),
+			Status:       BatchStatus(strings.TrimSpace(record[2])),
+		}
+		if result.Status == BatchMatch && len(record) >= 6 {
+			result.Exact = strings.TrimSpace(record[3]) == "Exact"
+			result.MatchedAddress = strings.TrimSpace(record[4])
+			result.Coords, err = parseBatchCoords(record[5])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse coordinates for %s: %w", result.ID, err)
+			}
+			if len(record) >= 8 {
+				result.TigerLineID = strings.TrimSpace(record[6])
+				result.Side = strings.TrimSpace(record[7])
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// parseBatchCoords reads the "longitude,latitude" column of a batch match
+func parseBatchCoords(field string) (*Coordinates, error) {
+	x, y, ok := strings.Cut(field, ",")
+	if !ok {
+		return nil, fmt.Errorf("want longitude,latitude, got %q", field)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+	if err != nil {
+		return nil, err
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(y), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &Coordinates{Latitude: lat, Longitude: lon}, nil
+}
@@ -0,0 +1,86 @@
+package geocode
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCensusGeocodeBatch(t *testing.T) {
+	var uploaded [][]string
+	var benchmark string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/locations/addressbatch" {
+			t.Errorf("got %s %s, want POST /locations/addressbatch", r.Method, r.URL.Path)
+		}
+		file, _, err := r.FormFile("addressFile")
+		if err != nil {
+			t.Fatalf("no addressFile in upload: %v", err)
+		}
+		uploaded, _ = csv.NewReader(file).ReadAll()
+		benchmark = r.FormValue("benchmark")
+
+		// The service answers in its own order
+		w.Write([]byte(`"3","1 Nowhere Rd, Nowhere, VA, ","No_Match"
+"1","120 E Main St, Charlottesville, VA, 22902","Match","Exact","120 E MAIN ST, CHARLOTTESVILLE, VA, 22902","-78.4795,38.0301","71234567","L"
+"2","9 Elm St, Springfield, VA, ","Tie"
+`))
+	}))
+	defer srv.Close()
+
+	census := NewCensus(&Client{BaseURL: srv.URL})
+	results, err := census.GeocodeBatch(context.Background(), []BatchAddress{
+		{ID: "1", Street: "120 E Main St", City: "Charlottesville", State: "VA", Zip: "22902"},
+		{ID: "2", Street: "9 Elm St", City: "Springfield", State: "VA"},
+		{ID: "3", Street: "1 Nowhere Rd", City: "Nowhere", State: "VA"},
+	})
+	if err != nil {
+		t.Fatalf("GeocodeBatch() error = %v", err)
+	}
+
+	if len(uploaded) != 3 || len(uploaded[0]) != 5 || uploaded[0][1] != "120 E Main St" || uploaded[0][4] != "22902" {
+		t.Errorf("uploaded CSV = %q", uploaded)
+	}
+	if benchmark != census.Benchmark {
+		t.Errorf("benchmark = %q, want %q", benchmark, census.Benchmark)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	wantStatus := []BatchStatus{BatchMatch, BatchTie, BatchNoMatch}
+	for i, result := range results {
+		if result.Status != wantStatus[i] {
+			t.Errorf("results[%d].Status = %q, want %q", i, result.Status, wantStatus[i])
+		}
+	}
+
+	match := results[0]
+	if match.ID != "1" || !match.Exact || match.TigerLineID != "71234567" || match.Side != "L" ||
+		match.MatchedAddress != "120 E MAIN ST, CHARLOTTESVILLE, VA, 22902" {
+		t.Errorf("match = %+v", match)
+	}
+	if match.Coords == nil || match.Coords.Latitude != 38.0301 || match.Coords.Longitude != -78.4795 {
+		t.Errorf("match.Coords = %+v, want 38.0301, -78.4795", match.Coords)
+	}
+	if r := match.Result(); r.Error != nil || r.Provider != "census" || r.MatchType != MatchInterpolated {
+		t.Errorf("match.Result() = %+v", r)
+	}
+	if r := results[1].Result(); r.Error != ErrNoResults {
+		t.Errorf("tie Result().Error = %v, want ErrNoResults", r.Error)
+	}
+}
+
+func TestCensusGeocodeBatchServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad file", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	census := NewCensus(&Client{BaseURL: srv.URL})
+	if _, err := census.GeocodeBatch(context.Background(), []BatchAddress{{ID: "1"}}); err == nil {
+		t.Error("GeocodeBatch() error = nil, want HTTP error")
+	}
+}
@@ -0,0 +1,180 @@
+package geocode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Client holds the connection settings for one geocoding service
+type Client struct {
+	// BaseURL is the service root, such as https://nominatim.openstreetmap.org
+	// or a self-hosted instance
+	BaseURL string
+	// UserAgent identifies this project to the service
+	UserAgent string
+	// Email is a contact address for services that ask for one
+	Email string
+	// HTTPClient makes the requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+	// Timeout bounds each request; 0 means no limit beyond the context
+	Timeout time.Duration
+	// RateLimit is the minimum gap between requests; 0 means no limit
+	RateLimit time.Duration
+	// Retry controls how transient failures are retried
+	Retry RetryPolicy
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewClient returns a client for baseURL with the project's User-Agent, a
+// 10 second timeout, DefaultRetryPolicy and no rate limit
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:   baseURL,
+		UserAgent: userAgent,
+		Timeout:   10 * time.Second,
+		Retry:     DefaultRetryPolicy,
+	}
+}
+
+// GetJSON requests path with params from the service and decodes the JSON
+// response body into v. It waits for the rate limit before each attempt
+// and retries transient failures according to the client's policy.
+func (c *Client) GetJSON(ctx context.Context, path string, params url.Values, v interface{}) error {
+	apiURL := c.BaseURL + path
+	if len(params) > 0 {
+		apiURL += "?" + params.Encode()
+	}
+
+	return c.Retry.do(ctx, func() error {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		return c.getJSON(ctx, apiURL, v)
+	})
+}
+
+// wait blocks until the rate limit allows another request
+func (c *Client) wait(ctx context.Context) error {
+	if c.RateLimit <= 0 {
+		return nil
+	}
+
+	// Reserve the next slot, then sleep until it arrives
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.next
+	if slot.Before(now) {
+		slot = now
+	}
+	c.next = slot.Add(c.RateLimit)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getJSON fetches apiURL once and decodes the JSON response body into v
+func (c *Client) getJSON(ctx context.Context, apiURL string, v interface{}) error {
+	body, err := c.send(ctx, "GET", apiURL, "", nil, c.Timeout)
+	if err != nil {
+		return err
+	}
+
+	// Parse JSON response
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return nil
+}
+
+// send makes one request and returns the response body, giving up after
+// timeout if it is non-zero
+func (c *Client) send(ctx context.Context, method, apiURL, contentType string, payload []byte, timeout time.Duration) ([]byte, error) {
+	reqCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+
+	// Create request with required User-Agent header
+	req, err := http.NewRequestWithContext(reqCtx, method, apiURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Make the request. Our own timeout is transient, but the caller
+	// giving up is not.
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	// Handle HTTP errors
+	if resp.StatusCode != 200 {
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Host:       req.URL.Host,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &NetworkError{Err: fmt.Errorf("failed to read response: %w", err)}
+	}
+	return body, nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date, returning 0 if it is missing or unreadable
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(when); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
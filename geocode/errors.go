@@ -0,0 +1,93 @@
+package geocode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrNoResults is returned when a provider has no match for the query.
+	// It is permanent; asking again won't help.
+	ErrNoResults = errors.New("no results found for address")
+
+	// ErrTransient matches failures that may succeed if retried: rate
+	// limiting, server errors and network trouble
+	ErrTransient = errors.New("transient geocoding failure")
+
+	// ErrRateLimited matches an HTTP 429 from the provider
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrEmptyGazetteer is returned by NewOffline when the bundled
+	// gazetteer has no rows; `just gazetteer` fills it in
+	ErrEmptyGazetteer = errors.New("the bundled gazetteer is empty, run `just gazetteer` to fill it in")
+)
+
+// HTTPError is returned when a provider answers with a status other than 200
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Host       string
+	// RetryAfter is the wait the provider asked for, or 0 if it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return fmt.Sprintf("rate limited by %s (HTTP 429)", e.Host)
+	}
+	return fmt.Sprintf("HTTP error: %s", e.Status)
+}
+
+// Is lets errors.Is match ErrRateLimited and ErrTransient. Rate limiting
+// and 5xx server errors are transient; other statuses are not.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrTransient:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	}
+	return false
+}
+
+// NetworkError is returned when the provider couldn't be reached or the
+// request timed out. It is always transient.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("HTTP request failed: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Is lets errors.Is match ErrTransient
+func (e *NetworkError) Is(target error) bool {
+	return target == ErrTransient
+}
+
+// RetryError is returned when a request still failed after retrying. It
+// wraps the last failure, so errors.Is(err, ErrTransient) still holds.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether err is a failure that may succeed later, as
+// opposed to a permanent one like ErrNoResults
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
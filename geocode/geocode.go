@@ -1,101 +1,291 @@
 package geocode
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"sort"
+	"strings"
 	"time"
 )
 
+// userAgent identifies this project to the geocoding services
+const userAgent = "quilt-shop-proximity/1.0 (github.com/chicks-net/quilt-shop-proximity)"
+
 // Coordinates represents GPS coordinates
 type Coordinates struct {
 	Latitude  float64
 	Longitude float64
 }
 
+// BoundingBox is the extent of the matched place
+type BoundingBox struct {
+	South float64
+	North float64
+	West  float64
+	East  float64
+}
+
+// Address holds the structured parts of the matched address
+type Address struct {
+	HouseNumber string
+	Road        string
+	City        string
+	County      string
+	State       string
+	Postcode    string
+	Country     string
+}
+
+// MatchType describes how precisely a result pins down the address
+type MatchType string
+
+// Match types from most to least precise
+const (
+	// MatchRooftop is a building, shop or exact address point
+	MatchRooftop MatchType = "rooftop"
+	// MatchInterpolated is estimated along a street's address range
+	MatchInterpolated MatchType = "interpolated"
+	// MatchStreet is somewhere on the named street
+	MatchStreet MatchType = "street"
+	// MatchLocality is the centroid of a city, town or postcode
+	MatchLocality MatchType = "locality"
+	// MatchRegion is the centroid of a county, state or larger area
+	MatchRegion MatchType = "region"
+	// MatchUnknown means the provider didn't say
+	MatchUnknown MatchType = ""
+)
+
 // Result represents the result of a geocoding operation
 type Result struct {
 	Coords *Coordinates
 	Error  error
-}
 
-// nominatimResponse represents the JSON response from Nominatim API
-type nominatimResponse struct {
-	Lat string `json:"lat"`
-	Lon string `json:"lon"`
+	// Provider names the service that answered
+	Provider string
+	// Raw holds the provider's JSON for the matched place
+	Raw json.RawMessage
+	// Cached reports that the answer came from a Cache, not the network
+	Cached bool
+
+	// DisplayName is the provider's full label for the match
+	DisplayName string
+	// Class and Type are the OpenStreetMap tag of the match, like shop/fabric
+	Class string
+	Type  string
+	// Confidence is the provider's importance or confidence score from 0 to 1,
+	// or 0 when it doesn't report one
+	Confidence  float64
+	MatchType   MatchType
+	BoundingBox *BoundingBox
+	Address     Address
+
+	// Warning notes a doubt about the match, such as being in another city
+	Warning string
+	// Rejected lists the candidates a Validated geocoder passed over
+	Rejected []Rejection
 }
 
-// rateLimiter ensures we don't exceed 1 request per second to Nominatim
-var rateLimiter = time.NewTicker(1 * time.Second)
+// IsApproximate reports whether the coordinates are probably just the middle
+// of a town or larger area rather than the address itself
+func (r Result) IsApproximate() bool {
+	return r.MatchType == MatchLocality || r.MatchType == MatchRegion
+}
 
-// GeocodeAddress queries the Nominatim API to get GPS coordinates for an address
-func GeocodeAddress(address string) Result {
-	// Wait for rate limiter
-	<-rateLimiter.C
+// Geocoder turns an address query into coordinates
+type Geocoder interface {
+	Geocode(ctx context.Context, query Query) (Result, error)
+}
 
-	// URL encode the address
-	encodedAddress := url.QueryEscape(address)
-	apiURL := fmt.Sprintf("https://nominatim.openstreetmap.org/search?format=json&q=%s&limit=1", encodedAddress)
+// CandidateGeocoder can return several possible matches for a query, so a
+// caller can pass over a wrong best match
+type CandidateGeocoder interface {
+	Geocoder
+	GeocodeCandidates(ctx context.Context, query Query, limit int) ([]Result, error)
+}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// decodeAll decodes up to limit raw matches, returning ErrNoResults if
+// there are none
+func decodeAll(raws []json.RawMessage, limit int, decode func(json.RawMessage) (Result, error)) ([]Result, error) {
+	if len(raws) == 0 {
+		return nil, ErrNoResults
+	}
+	if limit > 0 && len(raws) > limit {
+		raws = raws[:limit]
 	}
 
-	// Create request with required User-Agent header
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return Result{Error: fmt.Errorf("failed to create request: %w", err)}
+	results := make([]Result, 0, len(raws))
+	for _, raw := range raws {
+		result, err := decode(raw)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
 	}
-	req.Header.Set("User-Agent", "quilt-shop-proximity/1.0 (github.com/chicks-net/quilt-shop-proximity)")
+	return results, nil
+}
 
-	// Make the request
-	resp, err := client.Do(req)
+// first returns the best of several matches as a single Result
+func first(results []Result, err error) (Result, error) {
 	if err != nil {
-		return Result{Error: fmt.Errorf("HTTP request failed: %w", err)}
+		return Result{Error: err}, err
 	}
-	defer resp.Body.Close()
+	return results[0], nil
+}
 
-	// Handle HTTP errors
-	if resp.StatusCode == 429 {
-		return Result{Error: fmt.Errorf("rate limited by Nominatim (HTTP 429)")}
-	}
-	if resp.StatusCode != 200 {
-		return Result{Error: fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)}
+// provider describes one of the built-in geocoders
+type provider struct {
+	// baseURL is the public instance, empty if there isn't one
+	baseURL string
+	// rateLimit is the public instance's usage policy
+	rateLimit time.Duration
+	build     func(*Client) Geocoder
+}
+
+// providers maps provider names to the built-in geocoders
+var providers = map[string]provider{
+	"nominatim": {nominatimURL, nominatimRateLimit, func(c *Client) Geocoder { return NewNominatim(c) }},
+	"census":    {censusURL, 0, func(c *Client) Geocoder { return NewCensus(c) }},
+	"photon":    {photonURL, photonRateLimit, func(c *Client) Geocoder { return NewPhoton(c) }},
+	"pelias":    {"", 0, func(c *Client) Geocoder { return NewPelias(c) }},
+}
+
+// decoders turn a provider's raw JSON for a match back into a Result, so
+// cached answers keep their details
+var decoders = map[string]func(json.RawMessage) (Result, error){
+	"nominatim": decodeNominatim,
+	"census":    decodeCensus,
+	"photon":    func(raw json.RawMessage) (Result, error) { return decodeFeature(raw, "photon") },
+	"pelias":    func(raw json.RawMessage) (Result, error) { return decodeFeature(raw, "pelias") },
+}
+
+// Providers returns the names of the built-in geocoding providers
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return Result{Error: fmt.Errorf("failed to read response: %w", err)}
+// New returns the geocoder for a provider spec. A spec is a provider name,
+// optionally followed by =URL to use a self-hosted instance, such as
+// "nominatim=http://localhost:8080". A comma separated list of specs
+// returns a Chain that tries each provider in order.
+//
+// Each provider starts from the settings of its public instance. Any
+// non-zero UserAgent, Email, HTTPClient, Timeout, RateLimit or Retry in
+// shared overrides them; shared may be nil. Self-hosted instances have no
+// rate limit unless shared sets one.
+func New(spec string, shared *Client) (Geocoder, error) {
+	var chain Chain
+	for _, item := range strings.Split(spec, ",") {
+		name, baseURL, _ := strings.Cut(strings.TrimSpace(item), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		p, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown geocoding provider %q (choose from %s)", name, strings.Join(Providers(), ", "))
+		}
+
+		client := NewClient(p.baseURL)
+		client.RateLimit = p.rateLimit
+		if baseURL = strings.TrimSpace(baseURL); baseURL != "" {
+			client.BaseURL = strings.TrimSuffix(baseURL, "/")
+			client.RateLimit = 0
+		}
+		if client.BaseURL == "" {
+			return nil, fmt.Errorf("geocoding provider %s needs a URL, like %s=https://example.com", name, name)
+		}
+		if shared != nil {
+			applyShared(client, shared)
+		}
+		chain = append(chain, p.build(client))
 	}
 
-	// Parse JSON response
-	var results []nominatimResponse
-	if err := json.Unmarshal(body, &results); err != nil {
-		return Result{Error: fmt.Errorf("failed to parse JSON: %w", err)}
+	switch len(chain) {
+	case 0:
+		return nil, fmt.Errorf("no geocoding provider given")
+	case 1:
+		return chain[0], nil
 	}
+	return chain, nil
+}
 
-	// Check if we got any results
-	if len(results) == 0 {
-		return Result{Error: fmt.Errorf("no results found for address")}
+// applyShared copies the non-zero settings of shared onto client
+func applyShared(client, shared *Client) {
+	if shared.UserAgent != "" {
+		client.UserAgent = shared.UserAgent
+	}
+	if shared.Email != "" {
+		client.Email = shared.Email
+	}
+	if shared.HTTPClient != nil {
+		client.HTTPClient = shared.HTTPClient
+	}
+	if shared.Timeout != 0 {
+		client.Timeout = shared.Timeout
 	}
+	if shared.RateLimit != 0 {
+		client.RateLimit = shared.RateLimit
+	}
+	if shared.Retry != (RetryPolicy{}) {
+		client.Retry = shared.Retry
+	}
+}
 
-	// Parse latitude and longitude from strings
-	var lat, lon float64
-	if _, err := fmt.Sscanf(results[0].Lat, "%f", &lat); err != nil {
-		return Result{Error: fmt.Errorf("failed to parse latitude: %w", err)}
+// Chain tries each geocoder in turn until one of them finds the address
+type Chain []Geocoder
+
+// Geocode returns the first successful result. If every geocoder in the
+// chain failed it returns ErrNoResults only when all of them had no match,
+// so that a transient failure is never mistaken for a missing address.
+func (c Chain) Geocode(ctx context.Context, query Query) (Result, error) {
+	err := fmt.Errorf("no geocoders in chain")
+	var failure error
+	var rejected []Rejection
+	for _, g := range c {
+		var result Result
+		result, err = g.Geocode(ctx, query)
+		rejected = append(rejected, result.Rejected...)
+		if err == nil {
+			result.Rejected = rejected
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return Result{Error: ctx.Err()}, ctx.Err()
+		}
+		if !errors.Is(err, ErrNoResults) {
+			failure = err
+		}
 	}
-	if _, err := fmt.Sscanf(results[0].Lon, "%f", &lon); err != nil {
-		return Result{Error: fmt.Errorf("failed to parse longitude: %w", err)}
+	if failure != nil {
+		err = failure
 	}
+	return Result{Error: err, Rejected: rejected}, err
+}
+
+// defaultGeocoder backs GeocodeAddress
+var defaultGeocoder = NewNominatim(nil)
 
-	return Result{
-		Coords: &Coordinates{
-			Latitude:  lat,
-			Longitude: lon,
-		},
+// defaultCache is consulted by GeocodeAddress when set with UseCache
+var defaultCache *Cache
+
+// UseCache makes GeocodeAddress look up and store results in cache
+func UseCache(cache *Cache) {
+	defaultCache = cache
+}
+
+// GeocodeAddress queries the Nominatim API to get GPS coordinates for an address
+func GeocodeAddress(address string) Result {
+	var g Geocoder = defaultGeocoder
+	if defaultCache != nil {
+		g = defaultCache.Wrap(g, "nominatim")
 	}
+	result, _ := g.Geocode(context.Background(), FreeText(address))
+	return result
 }
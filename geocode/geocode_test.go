@@ -0,0 +1,343 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// jsonServer starts a test server that answers every request with body
+func jsonServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		geocoder func(baseURL string) Geocoder
+		body     string
+		wantLat  float64
+		wantLon  float64
+	}{
+		{
+			name:     "nominatim",
+			geocoder: func(u string) Geocoder { return NewNominatim(&Client{BaseURL: u}) },
+			body:     `[{"lat":"38.0293","lon":"-78.4767"}]`,
+			wantLat:  38.0293,
+			wantLon:  -78.4767,
+		},
+		{
+			name:     "census",
+			geocoder: func(u string) Geocoder { return NewCensus(&Client{BaseURL: u}) },
+			body:     `{"result":{"addressMatches":[{"matchedAddress":"1 MAIN ST","coordinates":{"x":-78.4767,"y":38.0293}}]}}`,
+			wantLat:  38.0293,
+			wantLon:  -78.4767,
+		},
+		{
+			name:     "photon",
+			geocoder: func(u string) Geocoder { return NewPhoton(&Client{BaseURL: u}) },
+			body:     `{"type":"FeatureCollection","features":[{"geometry":{"type":"Point","coordinates":[-78.4767,38.0293]}}]}`,
+			wantLat:  38.0293,
+			wantLon:  -78.4767,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := jsonServer(t, tt.body)
+			result, err := tt.geocoder(srv.URL).Geocode(context.Background(), FreeText("1 Main St, Charlottesville, VA"))
+			if err != nil {
+				t.Fatalf("Geocode() error = %v", err)
+			}
+			if result.Coords.Latitude != tt.wantLat || result.Coords.Longitude != tt.wantLon {
+				t.Errorf("Geocode() = %.4f, %.4f, want %.4f, %.4f",
+					result.Coords.Latitude, result.Coords.Longitude, tt.wantLat, tt.wantLon)
+			}
+		})
+	}
+}
+
+func TestProvidersNoResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		geocoder func(baseURL string) Geocoder
+		body     string
+	}{
+		{"nominatim", func(u string) Geocoder { return NewNominatim(&Client{BaseURL: u}) }, `[]`},
+		{"census", func(u string) Geocoder { return NewCensus(&Client{BaseURL: u}) }, `{"result":{"addressMatches":[]}}`},
+		{"photon", func(u string) Geocoder { return NewPhoton(&Client{BaseURL: u}) }, `{"features":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := jsonServer(t, tt.body)
+			result, err := tt.geocoder(srv.URL).Geocode(context.Background(), FreeText("nowhere"))
+			if err == nil {
+				t.Fatal("Geocode() error = nil, want error")
+			}
+			if result.Error == nil || result.Coords != nil {
+				t.Errorf("Geocode() result = %+v, want only Error set", result)
+			}
+		})
+	}
+}
+
+// stubGeocoder returns a canned result
+type stubGeocoder struct {
+	result Result
+	err    error
+	calls  int
+}
+
+func (s *stubGeocoder) Geocode(ctx context.Context, query Query) (Result, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func TestChain(t *testing.T) {
+	miss := &stubGeocoder{err: errors.New("no results found for address")}
+	hit := &stubGeocoder{result: Result{Coords: &Coordinates{Latitude: 1, Longitude: 2}}}
+	unused := &stubGeocoder{err: errors.New("should not be called")}
+
+	result, err := Chain{miss, hit, unused}.Geocode(context.Background(), FreeText("somewhere"))
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if result.Coords.Latitude != 1 || result.Coords.Longitude != 2 {
+		t.Errorf("Geocode() = %+v, want coordinates from second geocoder", result.Coords)
+	}
+	if miss.calls != 1 || hit.calls != 1 || unused.calls != 0 {
+		t.Errorf("calls = %d, %d, %d, want 1, 1, 0", miss.calls, hit.calls, unused.calls)
+	}
+
+	if _, err := (Chain{miss, miss}).Geocode(context.Background(), FreeText("nowhere")); err == nil {
+		t.Error("Geocode() with all misses error = nil, want error")
+	}
+}
+
+func TestNew(t *testing.T) {
+	g, err := New("nominatim", nil)
+	if err != nil {
+		t.Fatalf("New(nominatim) error = %v", err)
+	}
+	n, ok := g.(*Nominatim)
+	if !ok {
+		t.Fatalf("New(nominatim) = %T, want *Nominatim", g)
+	}
+	if n.BaseURL != nominatimURL || n.RateLimit != nominatimRateLimit {
+		t.Errorf("New(nominatim) client = %s every %v, want public instance", n.BaseURL, n.RateLimit)
+	}
+
+	g, err = New("nominatim, census", nil)
+	if err != nil {
+		t.Fatalf("New(nominatim, census) error = %v", err)
+	}
+	if chain, ok := g.(Chain); !ok || len(chain) != 2 {
+		t.Errorf("New(nominatim, census) = %T, want Chain of 2", g)
+	}
+
+	for _, spec := range []string{"bogus", "", "pelias"} {
+		if _, err := New(spec, nil); err == nil {
+			t.Errorf("New(%q) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestNewSelfHosted(t *testing.T) {
+	shared := &Client{Email: "quilts@example.com", Timeout: 30 * time.Second}
+	g, err := New("nominatim=http://localhost:8080/,pelias=http://pelias.local", shared)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	chain := g.(Chain)
+
+	n := chain[0].(*Nominatim)
+	if n.BaseURL != "http://localhost:8080" || n.RateLimit != 0 {
+		t.Errorf("self-hosted nominatim = %s every %v, want localhost with no rate limit", n.BaseURL, n.RateLimit)
+	}
+	if n.Email != shared.Email || n.Timeout != shared.Timeout || n.UserAgent != userAgent {
+		t.Errorf("self-hosted nominatim settings = %q, %v, %q, want shared settings", n.Email, n.Timeout, n.UserAgent)
+	}
+
+	p := chain[1].(*Photon)
+	if p.Name != "pelias" || p.BaseURL != "http://pelias.local" {
+		t.Errorf("pelias = %s at %s", p.Name, p.BaseURL)
+	}
+}
+
+func TestClientRequests(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`[{"lat":"1","lon":"2"}]`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.UserAgent = "quilt-guild-test/1.0"
+	client.Email = "quilts@example.com"
+	if _, err := NewNominatim(client).Geocode(context.Background(), FreeText("somewhere")); err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if got.URL.Path != "/search" || got.URL.Query().Get("email") != client.Email {
+		t.Errorf("request = %s, want /search with email", got.URL)
+	}
+	if ua := got.Header.Get("User-Agent"); ua != client.UserAgent {
+		t.Errorf("User-Agent = %q, want %q", ua, client.UserAgent)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	srv := jsonServer(t, `[{"lat":"1","lon":"2"}]`)
+	client := NewClient(srv.URL)
+	client.RateLimit = 20 * time.Millisecond
+	n := NewNominatim(client)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := n.Geocode(context.Background(), FreeText("somewhere")); err != nil {
+			t.Fatalf("Geocode() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 40ms", elapsed)
+	}
+
+	// A canceled context stops waiting for the next slot
+	client.RateLimit = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := n.Geocode(ctx, FreeText("somewhere")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Geocode() with canceled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.Timeout = 10 * time.Millisecond
+	client.Retry = RetryPolicy{}
+	_, err := NewNominatim(client).Geocode(context.Background(), FreeText("somewhere"))
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !IsTransient(err) {
+		t.Errorf("Geocode() error = %v, want transient NetworkError", err)
+	}
+}
+
+func TestProviderDetails(t *testing.T) {
+	tests := []struct {
+		name            string
+		geocoder        func(baseURL string) Geocoder
+		body            string
+		wantMatch       MatchType
+		wantApproximate bool
+		wantDisplay     string
+		wantAddress     Address
+		wantBox         *BoundingBox
+	}{
+		{
+			name:     "nominatim shop",
+			geocoder: func(u string) Geocoder { return NewNominatim(&Client{BaseURL: u}) },
+			body: `[{"lat":"33.8470","lon":"-117.9418","class":"shop","type":"fabric","place_rank":30,"importance":0.2,
+				"display_name":"Mel's Sewing, 1189, North Euclid Street, Anaheim, Orange County, California, 92801, United States",
+				"boundingbox":["33.8469","33.8471","-117.9419","-117.9417"],
+				"address":{"house_number":"1189","road":"North Euclid Street","city":"Anaheim","county":"Orange County",
+				"state":"California","postcode":"92801","country":"United States"}}]`,
+			wantMatch:   MatchRooftop,
+			wantDisplay: "Mel's Sewing, 1189, North Euclid Street, Anaheim, Orange County, California, 92801, United States",
+			wantAddress: Address{HouseNumber: "1189", Road: "North Euclid Street", City: "Anaheim", County: "Orange County",
+				State: "California", Postcode: "92801", Country: "United States"},
+			wantBox: &BoundingBox{South: 33.8469, North: 33.8471, West: -117.9419, East: -117.9417},
+		},
+		{
+			name:     "nominatim town",
+			geocoder: func(u string) Geocoder { return NewNominatim(&Client{BaseURL: u}) },
+			body: `[{"lat":"37.2710","lon":"-79.9414","class":"boundary","type":"administrative","place_rank":16,
+				"display_name":"Floyd, Floyd County, Virginia, United States",
+				"address":{"town":"Floyd","county":"Floyd County","state":"Virginia","country":"United States"}}]`,
+			wantMatch:       MatchLocality,
+			wantApproximate: true,
+			wantDisplay:     "Floyd, Floyd County, Virginia, United States",
+			wantAddress:     Address{City: "Floyd", County: "Floyd County", State: "Virginia", Country: "United States"},
+		},
+		{
+			name:     "census",
+			geocoder: func(u string) Geocoder { return NewCensus(&Client{BaseURL: u}) },
+			body: `{"result":{"addressMatches":[{"matchedAddress":"120 E MAIN ST, CHARLOTTESVILLE, VA, 22902",
+				"coordinates":{"x":-78.4795,"y":38.0301},
+				"addressComponents":{"preDirection":"E","streetName":"MAIN","suffixType":"ST","city":"CHARLOTTESVILLE","state":"VA","zip":"22902"}}]}}`,
+			wantMatch:   MatchInterpolated,
+			wantDisplay: "120 E MAIN ST, CHARLOTTESVILLE, VA, 22902",
+			wantAddress: Address{HouseNumber: "120", Road: "E MAIN ST", City: "CHARLOTTESVILLE", State: "VA",
+				Postcode: "22902", Country: "United States"},
+		},
+		{
+			name:     "photon",
+			geocoder: func(u string) Geocoder { return NewPhoton(&Client{BaseURL: u}) },
+			body: `{"features":[{"geometry":{"coordinates":[-78.4795,38.0301]},"properties":{"osm_key":"place","osm_value":"house",
+				"type":"house","housenumber":"120","street":"East Main Street","city":"Charlottesville","state":"Virginia",
+				"postcode":"22902","country":"United States","extent":[-78.48,38.031,-78.479,38.030]}}]}`,
+			wantMatch:   MatchRooftop,
+			wantDisplay: "120 East Main Street, Charlottesville, Virginia, 22902, United States",
+			wantAddress: Address{HouseNumber: "120", Road: "East Main Street", City: "Charlottesville", State: "Virginia",
+				Postcode: "22902", Country: "United States"},
+			wantBox: &BoundingBox{South: 38.030, North: 38.031, West: -78.48, East: -78.479},
+		},
+		{
+			name:     "pelias",
+			geocoder: func(u string) Geocoder { return NewPelias(&Client{BaseURL: u}) },
+			body: `{"features":[{"geometry":{"coordinates":[-78.4767,38.0293]},"properties":{"layer":"locality",
+				"label":"Charlottesville, VA, USA","locality":"Charlottesville","region_a":"VA","country":"United States",
+				"confidence":0.6}}]}`,
+			wantMatch:       MatchLocality,
+			wantApproximate: true,
+			wantDisplay:     "Charlottesville, VA, USA",
+			wantAddress:     Address{City: "Charlottesville", State: "VA", Country: "United States"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := jsonServer(t, tt.body)
+			result, err := tt.geocoder(srv.URL).Geocode(context.Background(), FreeText("somewhere"))
+			if err != nil {
+				t.Fatalf("Geocode() error = %v", err)
+			}
+			if result.MatchType != tt.wantMatch {
+				t.Errorf("MatchType = %q, want %q", result.MatchType, tt.wantMatch)
+			}
+			if result.IsApproximate() != tt.wantApproximate {
+				t.Errorf("IsApproximate() = %v, want %v", result.IsApproximate(), tt.wantApproximate)
+			}
+			if result.DisplayName != tt.wantDisplay {
+				t.Errorf("DisplayName = %q, want %q", result.DisplayName, tt.wantDisplay)
+			}
+			if result.Address != tt.wantAddress {
+				t.Errorf("Address = %+v, want %+v", result.Address, tt.wantAddress)
+			}
+			if (result.BoundingBox == nil) != (tt.wantBox == nil) ||
+				(tt.wantBox != nil && *result.BoundingBox != *tt.wantBox) {
+				t.Errorf("BoundingBox = %+v, want %+v", result.BoundingBox, tt.wantBox)
+			}
+			if len(result.Raw) == 0 {
+				t.Error("Raw is empty")
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// nominatimURL is the public OpenStreetMap Nominatim instance
+	nominatimURL = "https://nominatim.openstreetmap.org"
+	// nominatimRateLimit is the public instance's usage policy
+	nominatimRateLimit = time.Second
+)
+
+// nominatimPlace represents one place in the JSON response from Nominatim API
+type nominatimPlace struct {
+	Lat         string   `json:"lat"`
+	Lon         string   `json:"lon"`
+	Class       string   `json:"class"`
+	Type        string   `json:"type"`
+	PlaceRank   int      `json:"place_rank"`
+	Importance  float64  `json:"importance"`
+	DisplayName string   `json:"display_name"`
+	BoundingBox []string `json:"boundingbox"`
+	Address     struct {
+		HouseNumber string `json:"house_number"`
+		Road        string `json:"road"`
+		City        string `json:"city"`
+		Town        string `json:"town"`
+		Village     string `json:"village"`
+		Hamlet      string `json:"hamlet"`
+		County      string `json:"county"`
+		State       string `json:"state"`
+		Postcode    string `json:"postcode"`
+		Country     string `json:"country"`
+	} `json:"address"`
+}
+
+// Nominatim geocodes addresses with the OpenStreetMap Nominatim API
+type Nominatim struct {
+	*Client
+}
+
+// NewNominatim returns a Nominatim geocoder using client, or the public
+// instance at one request per second if client is nil
+func NewNominatim(client *Client) *Nominatim {
+	if client == nil {
+		client = NewClient(nominatimURL)
+		client.RateLimit = nominatimRateLimit
+	}
+	return &Nominatim{Client: client}
+}
+
+// Geocode queries the Nominatim API to get GPS coordinates for an address.
+// Structured queries use Nominatim's field-wise search first and fall back
+// to free text when that finds nothing.
+func (n *Nominatim) Geocode(ctx context.Context, query Query) (Result, error) {
+	return first(n.GeocodeCandidates(ctx, query, 1))
+}
+
+// GeocodeCandidates returns up to limit possible matches for query, best
+// first
+func (n *Nominatim) GeocodeCandidates(ctx context.Context, query Query, limit int) ([]Result, error) {
+	if query.IsStructured() {
+		params := url.Values{}
+		for name, value := range map[string]string{
+			"street":     query.Street,
+			"city":       query.City,
+			"county":     query.County,
+			"state":      query.State,
+			"postalcode": query.PostalCode,
+			"country":    query.Country,
+		} {
+			if value != "" {
+				params.Set(name, value)
+			}
+		}
+
+		results, err := n.search(ctx, params, limit)
+		if !errors.Is(err, ErrNoResults) {
+			return results, err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("q", query.String())
+	return n.search(ctx, params, limit)
+}
+
+// search runs one Nominatim search request and decodes up to limit matches
+func (n *Nominatim) search(ctx context.Context, params url.Values, limit int) ([]Result, error) {
+	params.Set("format", "json")
+	params.Set("addressdetails", "1")
+	params.Set("limit", strconv.Itoa(limit))
+	if n.Email != "" {
+		params.Set("email", n.Email)
+	}
+
+	var places []json.RawMessage
+	if err := n.GetJSON(ctx, "/search", params, &places); err != nil {
+		return nil, err
+	}
+	return decodeAll(places, limit, decodeNominatim)
+}
+
+// decodeNominatim turns one Nominatim place into a Result
+func decodeNominatim(raw json.RawMessage) (Result, error) {
+	var place nominatimPlace
+	if err := json.Unmarshal(raw, &place); err != nil {
+		return Result{}, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	// Parse latitude and longitude from strings
+	lat, err := strconv.ParseFloat(place.Lat, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse latitude: %w", err)
+	}
+	lon, err := strconv.ParseFloat(place.Lon, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse longitude: %w", err)
+	}
+
+	result := Result{
+		Coords: &Coordinates{
+			Latitude:  lat,
+			Longitude: lon,
+		},
+		Provider:    "nominatim",
+		Raw:         raw,
+		DisplayName: place.DisplayName,
+		Class:       place.Class,
+		Type:        place.Type,
+		Confidence:  place.Importance,
+		MatchType:   nominatimMatchType(place.PlaceRank),
+		Address: Address{
+			HouseNumber: place.Address.HouseNumber,
+			Road:        place.Address.Road,
+			City:        firstNonEmpty(place.Address.City, place.Address.Town, place.Address.Village, place.Address.Hamlet),
+			County:      place.Address.County,
+			State:       place.Address.State,
+			Postcode:    place.Address.Postcode,
+			Country:     place.Address.Country,
+		},
+	}
+
+	// Nominatim orders the bounding box south, north, west, east
+	if len(place.BoundingBox) == 4 {
+		var box [4]float64
+		valid := true
+		for i, s := range place.BoundingBox {
+			if box[i], err = strconv.ParseFloat(s, 64); err != nil {
+				valid = false
+			}
+		}
+		if valid {
+			result.BoundingBox = &BoundingBox{South: box[0], North: box[1], West: box[2], East: box[3]}
+		}
+	}
+
+	return result, nil
+}
+
+// nominatimMatchType maps a Nominatim place rank to a MatchType. Rank 30 is
+// a house or point of interest, 26-29 a street, 13-25 a city, town,
+// suburb or postcode and anything lower a county, state or country.
+func nominatimMatchType(placeRank int) MatchType {
+	switch {
+	case placeRank >= 30:
+		return MatchRooftop
+	case placeRank >= 26:
+		return MatchStreet
+	case placeRank >= 13:
+		return MatchLocality
+	case placeRank > 0:
+		return MatchRegion
+	}
+	return MatchUnknown
+}
+
+// firstNonEmpty returns the first of values that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
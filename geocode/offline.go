@@ -0,0 +1,218 @@
+package geocode
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gazetteerFiles holds the bundled Census gazetteer extracts, regenerated
+// with `just gazetteer`
+//
+//go:embed gazetteer/zcta.tsv gazetteer/places.tsv
+var gazetteerFiles embed.FS
+
+// zipRegex finds a 5-digit ZIP code, ignoring any ZIP+4 suffix
+var zipRegex = regexp.MustCompile(`\b(\d{5})(?:-\d{4})?\b`)
+
+// placeSuffixes are the legal descriptions the Census appends to place names
+var placeSuffixes = []string{" city", " town", " village", " borough", " municipality", " cdp"}
+
+// Offline geocodes without the network by looking up a ZIP code or city in
+// a gazetteer of Census centroids. Its answers are only the middle of the
+// ZIP code or town, so every result is a MatchLocality and IsApproximate.
+// It is meant as a last resort when the real geocoders find nothing.
+type Offline struct {
+	zips   map[string]Coordinates
+	places map[string]Coordinates
+	list   []Place
+}
+
+// Place is a populated place from the gazetteer
+type Place struct {
+	Name   string
+	State  string
+	Coords Coordinates
+}
+
+// NewOffline returns an Offline geocoder using the bundled gazetteer, or
+// ErrEmptyGazetteer if it hasn't been filled in
+func NewOffline() (*Offline, error) {
+	zcta, err := gazetteerFiles.Open("gazetteer/zcta.tsv")
+	if err != nil {
+		return nil, err
+	}
+	defer zcta.Close()
+
+	places, err := gazetteerFiles.Open("gazetteer/places.tsv")
+	if err != nil {
+		return nil, err
+	}
+	defer places.Close()
+
+	o, err := LoadOffline(zcta, places)
+	if err != nil {
+		return nil, err
+	}
+	if o.Len() == 0 {
+		return nil, ErrEmptyGazetteer
+	}
+	return o, nil
+}
+
+// LoadOffline returns an Offline geocoder reading tab separated gazetteers
+// in the Census format: zcta with GEOID, INTPTLAT and INTPTLONG columns, and
+// places with USPS, NAME, INTPTLAT and INTPTLONG. Other columns are ignored,
+// so the Census files can be used as downloaded. Either reader may be nil.
+func LoadOffline(zcta, places io.Reader) (*Offline, error) {
+	o := &Offline{
+		zips:   make(map[string]Coordinates),
+		places: make(map[string]Coordinates),
+	}
+
+	if zcta != nil {
+		err := readGazetteer(zcta, []string{"GEOID", "INTPTLAT", "INTPTLONG"}, func(fields []string, coords Coordinates) {
+			o.zips[fields[0]] = coords
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ZIP gazetteer: %w", err)
+		}
+	}
+
+	if places != nil {
+		err := readGazetteer(places, []string{"USPS", "NAME", "INTPTLAT", "INTPTLONG"}, func(fields []string, coords Coordinates) {
+			key := placeKey(fields[1], fields[0])
+			// Keep the first of several places sharing a name, usually the city
+			if _, ok := o.places[key]; !ok {
+				o.places[key] = coords
+			}
+			o.list = append(o.list, Place{Name: fields[1], State: StateCode(fields[0]), Coords: coords})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read place gazetteer: %w", err)
+		}
+	}
+
+	return o, nil
+}
+
+// readGazetteer calls add with the named columns and the coordinates from
+// the last two of them for each row of a tab separated file with a header
+func readGazetteer(r io.Reader, columns []string, add func(fields []string, coords Coordinates)) error {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return scanner.Err()
+	}
+
+	index := make(map[string]int)
+	for i, name := range strings.Split(scanner.Text(), "\t") {
+		index[strings.TrimSpace(name)] = i
+	}
+	positions := make([]int, len(columns))
+	for i, name := range columns {
+		pos, ok := index[name]
+		if !ok {
+			return fmt.Errorf("missing column %s", name)
+		}
+		positions[i] = pos
+	}
+
+	for scanner.Scan() {
+		row := strings.Split(scanner.Text(), "\t")
+		fields := make([]string, len(columns))
+		for i, pos := range positions {
+			if pos < len(row) {
+				fields[i] = strings.TrimSpace(row[pos])
+			}
+		}
+
+		lat, err := strconv.ParseFloat(fields[len(fields)-2], 64)
+		if err != nil {
+			continue
+		}
+		lon, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			continue
+		}
+		add(fields, Coordinates{Latitude: lat, Longitude: lon})
+	}
+	return scanner.Err()
+}
+
+// placeKey identifies a place by state and normalized name
+func placeKey(name, state string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, suffix := range placeSuffixes {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return StateCode(state) + "|" + normalizeCity(name)
+}
+
+// Len returns how many ZIP codes and places the gazetteer knows
+func (o *Offline) Len() int {
+	return len(o.zips) + len(o.places)
+}
+
+// Places returns the gazetteer's places in a state given by name or postal
+// code, or every place for an empty state, in gazetteer order
+func (o *Offline) Places(state string) []Place {
+	var places []Place
+	for _, p := range o.list {
+		if state == "" || p.State == StateCode(state) {
+			places = append(places, p)
+		}
+	}
+	return places
+}
+
+// Geocode returns the centroid of the query's ZIP code, or failing that of
+// its city. A free-text query is split with ParseAddressLine first.
+func (o *Offline) Geocode(ctx context.Context, query Query) (Result, error) {
+	if !query.IsStructured() {
+		query = ParseAddressLine(query.Text)
+	}
+
+	zip := query.PostalCode
+	if zip == "" {
+		if match := zipRegex.FindAllStringSubmatch(query.Text, -1); len(match) > 0 {
+			zip = match[len(match)-1][1]
+		}
+	}
+	if match := zipRegex.FindStringSubmatch(zip); match != nil {
+		if coords, ok := o.zips[match[1]]; ok {
+			return o.result(coords, "ZIP "+match[1], Address{
+				City:     query.City,
+				State:    query.State,
+				Postcode: match[1],
+			}), nil
+		}
+	}
+
+	if query.City != "" && query.State != "" {
+		if coords, ok := o.places[placeKey(query.City, query.State)]; ok {
+			return o.result(coords, query.City+", "+StateCode(query.State), Address{
+				City:  query.City,
+				State: query.State,
+			}), nil
+		}
+	}
+
+	return Result{Error: ErrNoResults}, ErrNoResults
+}
+
+// result builds an approximate answer for a gazetteer centroid
+func (o *Offline) result(coords Coordinates, label string, address Address) Result {
+	address.Country = "United States"
+	return Result{
+		Coords:      &coords,
+		Provider:    "offline",
+		DisplayName: label,
+		MatchType:   MatchLocality,
+		Address:     address,
+	}
+}
@@ -0,0 +1,101 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The centroids here are made up; only the lookups are being tested
+const (
+	testZCTA = "GEOID\tALAND\tINTPTLAT\tINTPTLONG                                                                                                   \n" +
+		"22902\t1\t38.0200\t-78.4700      \n" +
+		"92801\t1\t33.8400\t-117.9500\n"
+	testPlaces = "USPS\tGEOID\tNAME\tLSAD\tINTPTLAT\tINTPTLONG\n" +
+		"VA\t5114968\tCharlottesville city\t25\t38.0300\t-78.4800\n" +
+		"VA\t5181072\tVienna town\t43\t38.9000\t-77.2600\n" +
+		"KY\t2165226\tRichmond city\t25\t37.7300\t-84.2900\n"
+)
+
+func TestOfflineGeocode(t *testing.T) {
+	offline, err := LoadOffline(strings.NewReader(testZCTA), strings.NewReader(testPlaces))
+	if err != nil {
+		t.Fatalf("LoadOffline() error = %v", err)
+	}
+	if offline.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", offline.Len())
+	}
+
+	tests := []struct {
+		name    string
+		query   Query
+		wantLat float64
+		wantErr error
+	}{
+		{"structured zip", Query{Street: "1 Main St", City: "Anywhere", State: "VA", PostalCode: "22902"}, 38.02, nil},
+		{"zip+4", Query{City: "Anywhere", State: "VA", PostalCode: "22902-1234"}, 38.02, nil},
+		{"free text zip", FreeText("1189 N Euclid St, Anaheim, CA 92801"), 33.84, nil},
+		{"city", Query{Street: "120 E Main St", City: "Charlottesville", State: "VA"}, 38.03, nil},
+		{"city by state name", Query{City: "vienna", State: "Virginia"}, 38.90, nil},
+		{"unknown zip falls back to city", Query{City: "Vienna", State: "VA", PostalCode: "00000"}, 38.90, nil},
+		{"city in the wrong state", Query{City: "Richmond", State: "VA"}, 0, ErrNoResults},
+		{"nothing to go on", FreeText("somewhere"), 0, ErrNoResults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := offline.Geocode(context.Background(), tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Geocode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Coords.Latitude != tt.wantLat {
+				t.Errorf("Latitude = %v, want %v", result.Coords.Latitude, tt.wantLat)
+			}
+			if !result.IsApproximate() || result.Provider != "offline" {
+				t.Errorf("result = %+v, want an approximate offline match", result)
+			}
+		})
+	}
+}
+
+func TestOfflinePlaces(t *testing.T) {
+	offline, err := LoadOffline(nil, strings.NewReader(testPlaces))
+	if err != nil {
+		t.Fatalf("LoadOffline() error = %v", err)
+	}
+
+	virginia := offline.Places("Virginia")
+	if len(virginia) != 2 || virginia[0].Name != "Charlottesville city" || virginia[1].Name != "Vienna town" {
+		t.Errorf("Places(Virginia) = %+v, want Charlottesville and Vienna", virginia)
+	}
+	if virginia[0].State != "VA" || virginia[0].Coords.Latitude != 38.03 {
+		t.Errorf("Places(Virginia)[0] = %+v", virginia[0])
+	}
+	if all := offline.Places(""); len(all) != 3 {
+		t.Errorf("Places(\"\") returned %d places, want 3", len(all))
+	}
+}
+
+func TestNewOffline(t *testing.T) {
+	// The bundled gazetteer is only a header until `just gazetteer` runs
+	offline, err := NewOffline()
+	if errors.Is(err, ErrEmptyGazetteer) {
+		return
+	}
+	if err != nil {
+		t.Fatalf("NewOffline() error = %v", err)
+	}
+	if offline.Len() == 0 {
+		t.Error("NewOffline() returned an empty gazetteer, want ErrEmptyGazetteer")
+	}
+}
+
+func TestLoadOfflineMissingColumn(t *testing.T) {
+	if _, err := LoadOffline(strings.NewReader("ZIP\tLAT\tLON\n"), nil); err == nil {
+		t.Error("LoadOffline() error = nil, want missing column error")
+	}
+}
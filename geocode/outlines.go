@@ -0,0 +1,146 @@
+package geocode
+
+import "math"
+
+// stateOutlines are simplified outlines of the states with quilt shop
+// sources, each a ring of points around the border and coast. Virginia has
+// a second ring for the Eastern Shore. They follow the real lines to within
+// a few miles: close enough to tell a spot in the state from one in the
+// next state or offshore, though not which side of a border a shop is on.
+var stateOutlines = map[string][][]Coordinates{
+	"CA": {{
+		// Oregon and Nevada
+		{42.00, -124.21}, {42.00, -120.00}, {39.00, -120.00}, {35.00, -114.63},
+		// Colorado River
+		{34.85, -114.57}, {34.72, -114.49}, {34.46, -114.38}, {34.30, -114.14},
+		{34.05, -114.43}, {33.60, -114.52}, {33.28, -114.68}, {33.03, -114.50},
+		{32.88, -114.46}, {32.74, -114.58}, {32.72, -114.72},
+		// Mexico
+		{32.53, -117.12},
+		// Coast, north from San Diego
+		{32.67, -117.24}, {32.96, -117.27}, {33.20, -117.39}, {33.46, -117.71},
+		{33.60, -117.88}, {33.74, -118.11}, {33.71, -118.29}, {33.77, -118.42},
+		{34.01, -118.50}, {34.00, -118.81}, {34.09, -119.07}, {34.27, -119.29},
+		{34.41, -119.69}, {34.47, -120.02}, {34.45, -120.47}, {34.58, -120.65},
+		{34.90, -120.67}, {35.17, -120.75}, {35.37, -120.87}, {35.66, -121.28},
+		{36.00, -121.50}, {36.28, -121.89}, {36.52, -121.95}, {36.64, -121.93},
+		{36.80, -121.79}, {36.96, -122.02}, {37.11, -122.29}, {37.46, -122.44},
+		{37.78, -122.51}, {37.90, -122.68}, {37.99, -123.02}, {38.30, -123.06},
+		{38.52, -123.25}, {38.95, -123.73}, {39.30, -123.80}, {39.70, -123.83},
+		{40.00, -124.07}, {40.44, -124.41}, {40.80, -124.20}, {41.06, -124.14},
+		{41.55, -124.08}, {41.75, -124.20},
+	}},
+	"VA": {
+		{
+			// North Carolina and Tennessee, west from the coast
+			{36.55, -75.87}, {36.55, -76.92}, {36.54, -78.00}, {36.54, -79.00},
+			{36.54, -80.00}, {36.56, -80.84}, {36.59, -81.68}, {36.61, -82.30},
+			{36.59, -83.00}, {36.60, -83.68},
+			// Kentucky
+			{36.75, -83.36}, {36.90, -83.03}, {37.02, -82.85}, {37.15, -82.62},
+			{37.30, -82.30}, {37.54, -81.97},
+			// West Virginia
+			{37.40, -81.93}, {37.28, -81.75}, {37.20, -81.56}, {37.26, -81.25},
+			{37.33, -81.05}, {37.38, -80.86}, {37.48, -80.66}, {37.56, -80.42},
+			{37.62, -80.30}, {37.73, -80.22}, {37.85, -80.10}, {38.00, -79.96},
+			{38.20, -79.80}, {38.43, -79.69}, {38.58, -79.48}, {38.66, -79.30},
+			{38.79, -79.05}, {38.93, -78.85}, {39.03, -78.62}, {39.17, -78.47},
+			{39.35, -78.35}, {39.46, -78.34}, {39.38, -78.10}, {39.25, -77.83},
+			// Potomac River
+			{39.32, -77.72}, {39.27, -77.54}, {39.12, -77.47}, {39.07, -77.36},
+			{38.99, -77.25}, {38.93, -77.12}, {38.87, -77.05}, {38.80, -77.04},
+			{38.71, -77.06}, {38.63, -77.18}, {38.53, -77.28}, {38.38, -77.25},
+			{38.33, -77.04}, {38.16, -76.76}, {38.05, -76.50}, {37.89, -76.24},
+			// Chesapeake Bay and the Atlantic
+			{37.82, -76.27}, {37.61, -76.28}, {37.48, -76.27}, {37.30, -76.27},
+			{37.24, -76.39}, {37.10, -76.30}, {37.00, -76.31}, {36.96, -76.29},
+			{36.93, -76.10}, {36.93, -76.01}, {36.85, -75.97}, {36.70, -75.92},
+		},
+		{
+			// Eastern Shore, south from Maryland down the Atlantic side
+			{37.97, -75.66}, {38.03, -75.24}, {37.90, -75.34}, {37.72, -75.50},
+			{37.55, -75.58}, {37.35, -75.74}, {37.20, -75.85}, {37.11, -75.96},
+			{37.16, -76.02}, {37.27, -76.03}, {37.45, -75.96}, {37.60, -75.88},
+			{37.75, -75.87}, {37.87, -75.82},
+		},
+	},
+}
+
+// outlineMargin is how far outside a state's outline, in kilometers, a
+// point may be and still count as in the state when validating, allowing
+// for the outlines cutting across the real line by a few miles
+const outlineMargin = 5.0
+
+// InsideOutline reports whether c is inside the outline of a state given by
+// name or postal code. ok is false for states without an outline, where
+// only the bounding box from StateBounds is known.
+func InsideOutline(state string, c Coordinates) (in, ok bool) {
+	rings, ok := stateOutlines[StateCode(state)]
+	if !ok {
+		return false, false
+	}
+	for _, ring := range rings {
+		if ringContains(ring, c) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// nearOutline reports whether c is inside a state's outline or within
+// outlineMargin of it. ok is false for states without an outline.
+func nearOutline(state string, c Coordinates) (near, ok bool) {
+	in, ok := InsideOutline(state, c)
+	if in || !ok {
+		return in, ok
+	}
+	for _, ring := range stateOutlines[StateCode(state)] {
+		if ringDistance(ring, c) <= outlineMargin {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// ringContains reports whether c is inside ring, counting how many of its
+// edges a line due east from c crosses
+func ringContains(ring []Coordinates, c Coordinates) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > c.Latitude) == (b.Latitude > c.Latitude) {
+			continue
+		}
+		lon := a.Longitude + (c.Latitude-a.Latitude)/(b.Latitude-a.Latitude)*(b.Longitude-a.Longitude)
+		if c.Longitude < lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ringDistance returns the distance in kilometers from c to the nearest
+// edge of ring, on a flat map centered on c, which is close enough over a
+// few miles
+func ringDistance(ring []Coordinates, c Coordinates) float64 {
+	const kmPerDegree = 111.2
+	scale := math.Cos(c.Latitude * math.Pi / 180)
+	// project puts a point in kilometers east and north of c
+	project := func(p Coordinates) (x, y float64) {
+		return (p.Longitude - c.Longitude) * scale * kmPerDegree, (p.Latitude - c.Latitude) * kmPerDegree
+	}
+
+	nearest := math.Inf(1)
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		ax, ay := project(ring[j])
+		bx, by := project(ring[i])
+		// the point on the edge closest to c, at the origin
+		dx, dy := bx-ax, by-ay
+		t := 0.0
+		if length := dx*dx + dy*dy; length > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+		}
+		nearest = math.Min(nearest, math.Hypot(ax+t*dx, ay+t*dy))
+	}
+	return nearest
+}
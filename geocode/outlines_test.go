@@ -0,0 +1,72 @@
+package geocode
+
+import "testing"
+
+func TestInsideOutline(t *testing.T) {
+	for code, rings := range stateOutlines {
+		box, _ := StateBounds(code)
+		for _, ring := range rings {
+			for _, c := range ring {
+				if !box.Contains(c) {
+					t.Errorf("%s outline point %v is outside its bounds", code, c)
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		state string
+		c     Coordinates
+		want  bool
+	}{
+		{"Sacramento", "CA", Coordinates{38.58, -121.49}, true},
+		{"San Diego", "CA", Coordinates{32.72, -117.16}, true},
+		{"Death Valley", "CA", Coordinates{36.46, -116.87}, true},
+		{"Reno, NV", "CA", Coordinates{39.53, -119.81}, false},
+		{"Las Vegas, NV", "CA", Coordinates{36.17, -115.14}, false},
+		{"northeast corner of the box, in Nevada", "CA", Coordinates{41.648, -114.145}, false},
+		{"Tijuana", "CA", Coordinates{32.51, -117.04}, false},
+		{"offshore", "California", Coordinates{34.0, -121.0}, false},
+		{"Charlottesville", "VA", Coordinates{38.03, -78.48}, true},
+		{"Hampton", "VA", Coordinates{37.03, -76.35}, true},
+		{"Grundy", "VA", Coordinates{37.28, -82.10}, true},
+		{"Eastern Shore", "VA", Coordinates{37.35, -75.94}, true},
+		{"Washington, DC", "VA", Coordinates{38.90, -77.03}, false},
+		{"Harlan, KY", "VA", Coordinates{36.84, -83.32}, false},
+		{"Martinsburg, WV", "VA", Coordinates{39.46, -77.96}, false},
+		{"Raleigh, NC", "VA", Coordinates{35.78, -78.64}, false},
+		{"Chesapeake Bay", "VA", Coordinates{37.50, -76.10}, false},
+	}
+	for _, tt := range tests {
+		if in, ok := InsideOutline(tt.state, tt.c); !ok || in != tt.want {
+			t.Errorf("InsideOutline(%s, %s) = %v, %v, want %v", tt.state, tt.name, in, ok, tt.want)
+		}
+	}
+
+	if _, ok := InsideOutline("OR", Coordinates{44.94, -123.03}); ok {
+		t.Error("InsideOutline(OR) ok = true, want no outline")
+	}
+}
+
+func TestNearOutline(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		c     Coordinates
+		want  bool
+	}{
+		{"Charlottesville", "VA", Coordinates{38.03, -78.48}, true},
+		// The outline runs a little north of the Tennessee line here
+		{"Bristol", "VA", Coordinates{36.596, -82.188}, true},
+		{"Martinsburg, WV", "VA", Coordinates{39.46, -77.96}, false},
+		{"Chesapeake Bay", "VA", Coordinates{37.50, -76.10}, false},
+		{"Crystal Bay, NV", "CA", Coordinates{39.23, -119.97}, true},
+		{"Carson City, NV", "CA", Coordinates{39.16, -119.77}, false},
+	}
+	for _, tt := range tests {
+		if near, ok := nearOutline(tt.state, tt.c); !ok || near != tt.want {
+			t.Errorf("nearOutline(%s, %s) = %v, %v, want %v", tt.state, tt.name, near, ok, tt.want)
+		}
+	}
+}
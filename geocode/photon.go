@@ -0,0 +1,233 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// photonURL is the public Photon instance run by Komoot
+	photonURL = "https://photon.komoot.io"
+	// photonRateLimit keeps us within the public instance's fair use policy
+	photonRateLimit = time.Second
+)
+
+// featureCollection represents the GeoJSON response from Photon and Pelias
+type featureCollection struct {
+	Features []json.RawMessage `json:"features"`
+}
+
+// feature represents one GeoJSON feature from Photon or Pelias. Photon
+// reports OSM tags and an extent while Pelias reports a layer, a label, a
+// confidence score and a bbox.
+type feature struct {
+	// BBox is ordered min longitude, min latitude, max longitude, max latitude
+	BBox     []float64 `json:"bbox"`
+	Geometry struct {
+		// Coordinates are ordered longitude, latitude
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		// Photon
+		OSMKey   string `json:"osm_key"`
+		OSMValue string `json:"osm_value"`
+		Type     string `json:"type"`
+		City     string `json:"city"`
+		State    string `json:"state"`
+		Postcode string `json:"postcode"`
+		// Extent is ordered min longitude, max latitude, max longitude, min latitude
+		Extent []float64 `json:"extent"`
+
+		// Pelias
+		Layer      string  `json:"layer"`
+		Label      string  `json:"label"`
+		Locality   string  `json:"locality"`
+		RegionA    string  `json:"region_a"`
+		PostalCode string  `json:"postalcode"`
+		Confidence float64 `json:"confidence"`
+
+		// Both
+		Name        string `json:"name"`
+		HouseNumber string `json:"housenumber"`
+		Street      string `json:"street"`
+		County      string `json:"county"`
+		Country     string `json:"country"`
+	} `json:"properties"`
+}
+
+// Photon geocodes addresses with a Photon or Pelias compatible API. Both
+// answer with a GeoJSON FeatureCollection and differ only in the search
+// paths and parameter names.
+type Photon struct {
+	*Client
+	Name       string
+	SearchPath string
+	QueryParam string
+	// LimitParam caps the number of results
+	LimitParam string
+	// StructuredPath is the field-wise search, empty if there isn't one
+	StructuredPath string
+	// ReversePath is the reverse geocoding endpoint, which takes the point
+	// as PointParamPrefix+"lat" and PointParamPrefix+"lon"
+	ReversePath      string
+	PointParamPrefix string
+}
+
+// NewPhoton returns a Photon geocoder using client, or the public instance
+// at one request per second if client is nil
+func NewPhoton(client *Client) *Photon {
+	if client == nil {
+		client = NewClient(photonURL)
+		client.RateLimit = photonRateLimit
+	}
+	return &Photon{
+		Client:      client,
+		Name:        "photon",
+		SearchPath:  "/api",
+		QueryParam:  "q",
+		LimitParam:  "limit",
+		ReversePath: "/reverse",
+	}
+}
+
+// NewPelias returns a geocoder for the Pelias instance at client's BaseURL
+func NewPelias(client *Client) *Photon {
+	return &Photon{
+		Client:           client,
+		Name:             "pelias",
+		SearchPath:       "/v1/search",
+		QueryParam:       "text",
+		LimitParam:       "size",
+		StructuredPath:   "/v1/search/structured",
+		ReversePath:      "/v1/reverse",
+		PointParamPrefix: "point.",
+	}
+}
+
+// Geocode queries the Photon API to get GPS coordinates for an address.
+// Structured queries use the structured search when the instance has one,
+// as Pelias does, and fall back to free text when that finds nothing.
+func (p *Photon) Geocode(ctx context.Context, query Query) (Result, error) {
+	return first(p.GeocodeCandidates(ctx, query, 1))
+}
+
+// GeocodeCandidates returns up to limit possible matches for query, best
+// first
+func (p *Photon) GeocodeCandidates(ctx context.Context, query Query, limit int) ([]Result, error) {
+	if query.IsStructured() && p.StructuredPath != "" {
+		params := url.Values{}
+		for name, value := range map[string]string{
+			"address":    query.Street,
+			"locality":   query.City,
+			"county":     query.County,
+			"region":     query.State,
+			"postalcode": query.PostalCode,
+			"country":    query.Country,
+		} {
+			if value != "" {
+				params.Set(name, value)
+			}
+		}
+
+		results, err := p.search(ctx, p.StructuredPath, params, limit)
+		if !errors.Is(err, ErrNoResults) {
+			return results, err
+		}
+	}
+
+	params := url.Values{}
+	params.Set(p.QueryParam, query.String())
+	return p.search(ctx, p.SearchPath, params, limit)
+}
+
+// search runs one search request and decodes up to limit matches
+func (p *Photon) search(ctx context.Context, path string, params url.Values, limit int) ([]Result, error) {
+	params.Set(p.LimitParam, strconv.Itoa(limit))
+
+	var response featureCollection
+	if err := p.GetJSON(ctx, path, params, &response); err != nil {
+		return nil, err
+	}
+	return decodeAll(response.Features, limit, func(raw json.RawMessage) (Result, error) {
+		return decodeFeature(raw, p.Name)
+	})
+}
+
+// decodeFeature turns one Photon or Pelias feature into a Result
+func decodeFeature(raw json.RawMessage, provider string) (Result, error) {
+	var f feature
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return Result{}, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	coords := f.Geometry.Coordinates
+	if len(coords) < 2 {
+		return Result{}, fmt.Errorf("result has no point geometry")
+	}
+
+	props := f.Properties
+	result := Result{
+		Coords: &Coordinates{
+			Latitude:  coords[1],
+			Longitude: coords[0],
+		},
+		Provider:   provider,
+		Raw:        raw,
+		Class:      props.OSMKey,
+		Type:       firstNonEmpty(props.OSMValue, props.Layer),
+		Confidence: props.Confidence,
+		MatchType:  featureMatchType(firstNonEmpty(props.Layer, props.Type)),
+		Address: Address{
+			HouseNumber: props.HouseNumber,
+			Road:        props.Street,
+			City:        firstNonEmpty(props.City, props.Locality),
+			County:      props.County,
+			State:       firstNonEmpty(props.RegionA, props.State),
+			Postcode:    firstNonEmpty(props.Postcode, props.PostalCode),
+			Country:     props.Country,
+		},
+	}
+
+	// Photon has no label, so build one from the address parts
+	result.DisplayName = props.Label
+	if result.DisplayName == "" {
+		street := strings.TrimSpace(props.HouseNumber + " " + props.Street)
+		var parts []string
+		for _, part := range []string{props.Name, street, props.City, props.State, props.Postcode, props.Country} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		result.DisplayName = strings.Join(parts, ", ")
+	}
+
+	switch {
+	case len(f.BBox) == 4:
+		result.BoundingBox = &BoundingBox{West: f.BBox[0], South: f.BBox[1], East: f.BBox[2], North: f.BBox[3]}
+	case len(props.Extent) == 4:
+		result.BoundingBox = &BoundingBox{West: props.Extent[0], North: props.Extent[1], East: props.Extent[2], South: props.Extent[3]}
+	}
+
+	return result, nil
+}
+
+// featureMatchType maps a Photon type or Pelias layer to a MatchType
+func featureMatchType(kind string) MatchType {
+	switch kind {
+	case "house", "address", "venue":
+		return MatchRooftop
+	case "street":
+		return MatchStreet
+	case "city", "locality", "localadmin", "district", "borough", "neighbourhood", "postalcode":
+		return MatchLocality
+	case "county", "state", "region", "macroregion", "country":
+		return MatchRegion
+	}
+	return MatchUnknown
+}
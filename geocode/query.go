@@ -0,0 +1,127 @@
+package geocode
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Query is an address to geocode, either as structured fields or as one
+// line of free text. Providers that support structured search use the
+// fields and fall back to free text when that finds nothing.
+type Query struct {
+	// Text is the free-text form; when empty it is built from the fields
+	Text string
+
+	Street     string
+	City       string
+	County     string
+	State      string
+	PostalCode string
+	Country    string
+}
+
+// FreeText returns a query that is only a line of text
+func FreeText(text string) Query {
+	return Query{Text: text}
+}
+
+// IsStructured reports whether any of the structured fields are set
+func (q Query) IsStructured() bool {
+	return q.Street != "" || q.City != "" || q.County != "" ||
+		q.State != "" || q.PostalCode != "" || q.Country != ""
+}
+
+// String returns the free-text form of the query
+func (q Query) String() string {
+	if q.Text != "" {
+		return q.Text
+	}
+
+	var parts []string
+	for _, part := range []string{q.Street, q.City, q.County, strings.TrimSpace(q.State + " " + q.PostalCode), q.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var (
+	// stateZipRegex matches the "CA 92801" at the end of a US address line
+	stateZipRegex = regexp.MustCompile(`^([A-Za-z]{2})\s+(\d{5}(?:-\d{4})?)$`)
+
+	// unitRegex matches suite, unit and similar designators within a street line
+	unitRegex = regexp.MustCompile(`(?i)(?:\b(?:suite|ste|unit|apt|bldg|building|space|spc|room|rm)\b\.?\s*#?|#)\s*[a-z0-9-]+\b`)
+
+	// complexRegex matches the name of a shopping center or similar complex
+	complexRegex = regexp.MustCompile(`(?i)\bshopping\b|\b(?:center|centre|ctr|mall|plaza|square|marketplace|village|crossing)\b`)
+)
+
+// CleanStreet strips the parts of a street address that confuse geocoders:
+// suite and unit numbers, and shopping center or plaza names on their own
+// line. When one of the comma separated parts starts with a house number,
+// that part alone is kept.
+func CleanStreet(street string) string {
+	var segments []string
+	for _, segment := range strings.Split(street, ",") {
+		segment = unitRegex.ReplaceAllString(segment, "")
+		segment = strings.Join(strings.Fields(segment), " ")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	for _, segment := range segments {
+		if segment[0] >= '0' && segment[0] <= '9' {
+			return segment
+		}
+	}
+
+	var kept []string
+	for _, segment := range segments {
+		if !complexRegex.MatchString(segment) {
+			kept = append(kept, segment)
+		}
+	}
+	if len(kept) == 0 {
+		kept = segments
+	}
+	return strings.Join(kept, ", ")
+}
+
+// ParseAddressLine splits a one-line US address such as
+// "1189 N Euclid St, Anaheim, CA 92801" into a structured query. The line
+// is kept as the query's free text so nothing is lost if it doesn't parse.
+func ParseAddressLine(line string) Query {
+	q := FreeText(line)
+
+	var segments []string
+	for _, segment := range strings.Split(line, ",") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) < 3 {
+		return q
+	}
+
+	// Drop a trailing country
+	if last := strings.ToUpper(segments[len(segments)-1]); last == "USA" || last == "US" || last == "UNITED STATES" {
+		q.Country = segments[len(segments)-1]
+		segments = segments[:len(segments)-1]
+		if len(segments) < 3 {
+			return FreeText(line)
+		}
+	}
+
+	match := stateZipRegex.FindStringSubmatch(segments[len(segments)-1])
+	if match == nil {
+		return FreeText(line)
+	}
+
+	q.State = strings.ToUpper(match[1])
+	q.PostalCode = match[2]
+	q.City = segments[len(segments)-2]
+	q.Street = CleanStreet(strings.Join(segments[:len(segments)-2], ", "))
+	return q
+}
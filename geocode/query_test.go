@@ -0,0 +1,118 @@
+package geocode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCleanStreet(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1234 Main St", "1234 Main St"},
+		{"1234 Main St, Suite 5", "1234 Main St"},
+		{"1234 Main St Ste. 5B,", "1234 Main St"},
+		{"1234 Main St #12", "1234 Main St"},
+		{"Gayton Crossing Shopping Center, 9782 Gayton Road", "9782 Gayton Road"},
+		{"Hilltop Plaza, Unit 3, 1300 Laskin Rd", "1300 Laskin Rd"},
+		{"Valley Mall, Route 11", "Route 11"},
+		{"Route 11", "Route 11"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CleanStreet(tt.input); got != tt.want {
+			t.Errorf("CleanStreet(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseAddressLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Query
+	}{
+		{
+			input: "1189 N Euclid St, Anaheim, CA 92801",
+			want: Query{Text: "1189 N Euclid St, Anaheim, CA 92801",
+				Street: "1189 N Euclid St", City: "Anaheim", State: "CA", PostalCode: "92801"},
+		},
+		{
+			input: "Stonecreek Plaza, 26 Main St, Suite B, Chico, ca 95928-1234, USA",
+			want: Query{Text: "Stonecreek Plaza, 26 Main St, Suite B, Chico, ca 95928-1234, USA",
+				Street: "26 Main St", City: "Chico", State: "CA", PostalCode: "95928-1234", Country: "USA"},
+		},
+		{
+			input: "Downtown Chico",
+			want:  Query{Text: "Downtown Chico"},
+		},
+		{
+			input: "26 Main St, Chico, California",
+			want:  Query{Text: "26 Main St, Chico, California"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := ParseAddressLine(tt.input); got != tt.want {
+			t.Errorf("ParseAddressLine(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	q := Query{Street: "9782 Gayton Road", City: "Richmond", State: "VA", Country: "USA"}
+	if got, want := q.String(), "9782 Gayton Road, Richmond, VA, USA"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !q.IsStructured() {
+		t.Error("IsStructured() = false, want true")
+	}
+
+	ft := FreeText("9782 Gayton Road, Richmond, VA")
+	if got := ft.String(); got != "9782 Gayton Road, Richmond, VA" {
+		t.Errorf("String() = %q, want the free text", got)
+	}
+	if ft.IsStructured() {
+		t.Error("IsStructured() = true for free text, want false")
+	}
+}
+
+func TestNominatimStructuredFallback(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		if r.URL.Query().Get("street") != "" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat":"37.6","lon":"-77.6","place_rank":30}]`))
+	}))
+	defer srv.Close()
+
+	n := NewNominatim(&Client{BaseURL: srv.URL})
+	q := Query{Street: "9782 Gayton Road", City: "Richmond", State: "VA"}
+	result, err := n.Geocode(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if result.Coords.Latitude != 37.6 {
+		t.Errorf("Latitude = %v, want 37.6", result.Coords.Latitude)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("made %d requests, want structured then free text", len(requests))
+	}
+
+	structured, _ := url.ParseQuery(requests[0])
+	if structured.Get("street") != "9782 Gayton Road" || structured.Get("city") != "Richmond" ||
+		structured.Get("state") != "VA" || structured.Has("q") {
+		t.Errorf("structured request = %v", structured)
+	}
+	freeText, _ := url.ParseQuery(requests[1])
+	if freeText.Get("q") != q.String() || freeText.Has("street") {
+		t.Errorf("free-text request = %v", freeText)
+	}
+}
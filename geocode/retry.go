@@ -0,0 +1,81 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how transient failures are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries; 1 or less never retries
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubling after each one
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After longer than this gives up
+	// rather than ignoring the provider's request.
+	MaxDelay time.Duration
+	// Jitter is the fraction of each delay, from 0 to 1, that is randomized
+	// so that parallel runs don't retry in lockstep
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by the built-in providers
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    time.Minute,
+	Jitter:      0.5,
+}
+
+// randFloat is swapped out by tests
+var randFloat = rand.Float64
+
+// backoff returns the jittered exponential wait before retry number n,
+// counting from 1
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay
+	// A MaxDelay of 0 means no cap
+	for i := 1; i < n && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay - time.Duration(p.Jitter*randFloat()*float64(delay))
+}
+
+// do calls fn until it succeeds, fails with a permanent error or runs out
+// of attempts. A Retry-After from the provider replaces the backoff.
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsTransient(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			if attempt > 1 {
+				return &RetryError{Attempts: attempt, Err: err}
+			}
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+			if p.MaxDelay > 0 && httpErr.RetryAfter > p.MaxDelay {
+				return &RetryError{Attempts: attempt, Err: err}
+			}
+			delay = httpErr.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fastRetry retries quickly so tests don't wait
+var fastRetry = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+}
+
+// flakyServer answers with each status in turn, then with body
+func flakyServer(t *testing.T, body string, headers map[string]string, statuses ...int) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= len(statuses) {
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(statuses[requests-1])
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestRetryTransient(t *testing.T) {
+	srv, requests := flakyServer(t, `[{"lat":"1","lon":"2"}]`, nil,
+		http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	n := NewNominatim(&Client{BaseURL: srv.URL, Retry: fastRetry})
+
+	result, err := n.Geocode(context.Background(), FreeText("somewhere"))
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if result.Coords.Latitude != 1 {
+		t.Errorf("Latitude = %v, want 1", result.Coords.Latitude)
+	}
+	if *requests != 3 {
+		t.Errorf("made %d requests, want 3", *requests)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	srv, requests := flakyServer(t, `[]`, nil,
+		http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	n := NewNominatim(&Client{BaseURL: srv.URL, Retry: fastRetry})
+
+	_, err := n.Geocode(context.Background(), FreeText("somewhere"))
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 {
+		t.Fatalf("Geocode() error = %v, want RetryError after 3 attempts", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("Geocode() error = %v, want HTTPError 502", err)
+	}
+	if !IsTransient(err) || errors.Is(err, ErrNoResults) {
+		t.Errorf("Geocode() error = %v, want transient", err)
+	}
+	if *requests != 3 {
+		t.Errorf("made %d requests, want 3", *requests)
+	}
+}
+
+func TestRetryPermanent(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		statuses []int
+		wantIs   error
+	}{
+		{name: "not found", body: `[]`, statuses: []int{http.StatusNotFound}},
+		{name: "no results", body: `[]`, wantIs: ErrNoResults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := flakyServer(t, tt.body, nil, tt.statuses...)
+			n := NewNominatim(&Client{BaseURL: srv.URL, Retry: fastRetry})
+
+			_, err := n.Geocode(context.Background(), FreeText("somewhere"))
+			if err == nil || IsTransient(err) {
+				t.Fatalf("Geocode() error = %v, want permanent error", err)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("Geocode() error = %v, want %v", err, tt.wantIs)
+			}
+			if *requests != 1 {
+				t.Errorf("made %d requests, want 1", *requests)
+			}
+		})
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	srv, requests := flakyServer(t, `[]`, map[string]string{"Retry-After": "3600"}, http.StatusTooManyRequests)
+	n := NewNominatim(&Client{BaseURL: srv.URL, Retry: fastRetry})
+
+	_, err := n.Geocode(context.Background(), FreeText("somewhere"))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Geocode() error = %v, want ErrRateLimited", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.RetryAfter != time.Hour {
+		t.Errorf("RetryAfter = %v, want 1h", httpErr.RetryAfter)
+	}
+	if *requests != 1 {
+		t.Errorf("made %d requests, want 1 since Retry-After exceeds MaxDelay", *requests)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	srv, _ := flakyServer(t, `[]`, nil, http.StatusServiceUnavailable)
+	n := NewNominatim(&Client{BaseURL: srv.URL, Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := n.Geocode(ctx, FreeText("somewhere")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Geocode() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	defer func(f func() float64) { randFloat = f }(randFloat)
+	randFloat = func() float64 { return 0 }
+
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, Jitter: 0.5}
+	for n, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := p.backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	// No cap still doubles
+	uncapped := RetryPolicy{BaseDelay: time.Second}
+	for n, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 7: 64 * time.Second} {
+		if got := uncapped.backoff(n); got != want {
+			t.Errorf("backoff(%d) without MaxDelay = %v, want %v", n, got, want)
+		}
+	}
+
+	randFloat = func() float64 { return 1 }
+	if got := p.backoff(2); got != time.Second {
+		t.Errorf("backoff(2) with full jitter = %v, want 1s", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("120"); got != 2*time.Minute {
+		t.Errorf("parseRetryAfter(120) = %v, want 2m", got)
+	}
+	if got := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); got < 59*time.Minute {
+		t.Errorf("parseRetryAfter(date) = %v, want about 1h", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("parseRetryAfter(soon) = %v, want 0", got)
+	}
+}
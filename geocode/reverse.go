@@ -0,0 +1,173 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ReverseGeocoder turns coordinates back into an address
+type ReverseGeocoder interface {
+	ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error)
+}
+
+// ReverseGeocode asks the public Nominatim instance what is at lat, lon
+func ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error) {
+	return defaultGeocoder.ReverseGeocode(ctx, lat, lon)
+}
+
+// formatCoord writes a coordinate without needless digits
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ReverseGeocode asks Nominatim for the address at lat, lon
+func (n *Nominatim) ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error) {
+	params := url.Values{}
+	params.Set("format", "json")
+	params.Set("addressdetails", "1")
+	params.Set("lat", formatCoord(lat))
+	params.Set("lon", formatCoord(lon))
+	if n.Email != "" {
+		params.Set("email", n.Email)
+	}
+
+	var raw json.RawMessage
+	if err := n.GetJSON(ctx, "/reverse", params, &raw); err != nil {
+		return Result{Error: err}, err
+	}
+
+	// Nominatim answers a point in the ocean with an error object
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(raw, &failure) == nil && failure.Error != "" {
+		return Result{Error: ErrNoResults}, ErrNoResults
+	}
+
+	result, err := decodeNominatim(raw)
+	if err != nil {
+		return Result{Error: err}, err
+	}
+	return result, nil
+}
+
+// ReverseGeocode asks Photon or Pelias for the address at lat, lon
+func (p *Photon) ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error) {
+	params := url.Values{}
+	params.Set(p.PointParamPrefix+"lat", formatCoord(lat))
+	params.Set(p.PointParamPrefix+"lon", formatCoord(lon))
+	return first(p.search(ctx, p.ReversePath, params, 1))
+}
+
+// censusGeographies represents the JSON response from the Census
+// geographies API. Each layer is a list of areas containing the point.
+type censusGeographies struct {
+	Result struct {
+		Geographies map[string][]struct {
+			Name   string `json:"NAME"`
+			StUSAB string `json:"STUSAB"`
+		} `json:"geographies"`
+	} `json:"result"`
+}
+
+// ReverseGeocode asks the Census Bureau which state, county and city or
+// town contain lat, lon. The Census has no street addresses for points, so
+// the result is a locality match.
+func (c *Census) ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error) {
+	params := url.Values{}
+	params.Set("x", formatCoord(lon))
+	params.Set("y", formatCoord(lat))
+	params.Set("benchmark", c.Benchmark)
+	params.Set("vintage", "Current_Current")
+	params.Set("format", "json")
+
+	var response censusGeographies
+	if err := c.GetJSON(ctx, "/geographies/coordinates", params, &response); err != nil {
+		return Result{Error: err}, err
+	}
+
+	layers := response.Result.Geographies
+	first := func(layer string) string {
+		if areas := layers[layer]; len(areas) > 0 {
+			return areas[0].Name
+		}
+		return ""
+	}
+	var state string
+	if states := layers["States"]; len(states) > 0 {
+		state = states[0].StUSAB
+	}
+	if state == "" {
+		return Result{Error: ErrNoResults}, ErrNoResults
+	}
+
+	address := Address{
+		City:    firstNonEmpty(first("Incorporated Places"), first("Census Designated Places"), first("County Subdivisions")),
+		County:  first("Counties"),
+		State:   state,
+		Country: "United States",
+	}
+	var parts []string
+	for _, part := range []string{address.City, address.County, address.State} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	raw, _ := json.Marshal(layers)
+	return Result{
+		Coords: &Coordinates{
+			Latitude:  lat,
+			Longitude: lon,
+		},
+		Provider:    "census",
+		Raw:         raw,
+		DisplayName: strings.Join(parts, ", "),
+		MatchType:   MatchLocality,
+		Address:     address,
+	}, nil
+}
+
+// ReverseGeocode returns the first answer from a geocoder in the chain that
+// can reverse geocode
+func (c Chain) ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error) {
+	err := fmt.Errorf("no geocoders in chain can reverse geocode")
+	var failure error
+	for _, g := range c {
+		reverse, ok := g.(ReverseGeocoder)
+		if !ok {
+			continue
+		}
+		var result Result
+		result, err = reverse.ReverseGeocode(ctx, lat, lon)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return Result{Error: ctx.Err()}, ctx.Err()
+		}
+		if !errors.Is(err, ErrNoResults) {
+			failure = err
+		}
+	}
+	if failure != nil {
+		err = failure
+	}
+	return Result{Error: err}, err
+}
+
+// ReverseGeocode passes straight through to the wrapped geocoder; reverse
+// lookups aren't cached
+func (c *Cached) ReverseGeocode(ctx context.Context, lat, lon float64) (Result, error) {
+	reverse, ok := c.Geocoder.(ReverseGeocoder)
+	if !ok {
+		err := fmt.Errorf("%T can't reverse geocode", c.Geocoder)
+		return Result{Error: err}, err
+	}
+	return reverse.ReverseGeocode(ctx, lat, lon)
+}
@@ -0,0 +1,112 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReverseGeocode(t *testing.T) {
+	tests := []struct {
+		name        string
+		geocoder    func(baseURL string) ReverseGeocoder
+		body        string
+		wantPath    string
+		wantParams  map[string]string
+		wantAddress Address
+	}{
+		{
+			name:     "nominatim",
+			geocoder: func(u string) ReverseGeocoder { return NewNominatim(&Client{BaseURL: u}) },
+			body: `{"lat":"38.0301","lon":"-78.4795","place_rank":30,"display_name":"120, East Main Street, Charlottesville",
+				"address":{"house_number":"120","road":"East Main Street","city":"Charlottesville","state":"Virginia"}}`,
+			wantPath:    "/reverse",
+			wantParams:  map[string]string{"lat": "38.0301", "lon": "-78.4795"},
+			wantAddress: Address{HouseNumber: "120", Road: "East Main Street", City: "Charlottesville", State: "Virginia"},
+		},
+		{
+			name:     "census",
+			geocoder: func(u string) ReverseGeocoder { return NewCensus(&Client{BaseURL: u}) },
+			body: `{"result":{"geographies":{"States":[{"NAME":"Virginia","STUSAB":"VA"}],
+				"Counties":[{"NAME":"Charlottesville city"}],"Incorporated Places":[{"NAME":"Charlottesville city"}]}}}`,
+			wantPath:    "/geographies/coordinates",
+			wantParams:  map[string]string{"x": "-78.4795", "y": "38.0301"},
+			wantAddress: Address{City: "Charlottesville city", County: "Charlottesville city", State: "VA", Country: "United States"},
+		},
+		{
+			name:     "pelias",
+			geocoder: func(u string) ReverseGeocoder { return NewPelias(&Client{BaseURL: u}) },
+			body: `{"features":[{"geometry":{"coordinates":[-78.4795,38.0301]},"properties":{"layer":"address",
+				"housenumber":"120","street":"East Main Street","locality":"Charlottesville","region_a":"VA"}}]}`,
+			wantPath:    "/v1/reverse",
+			wantParams:  map[string]string{"point.lat": "38.0301", "point.lon": "-78.4795"},
+			wantAddress: Address{HouseNumber: "120", Road: "East Main Street", City: "Charlottesville", State: "VA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			result, err := tt.geocoder(srv.URL).ReverseGeocode(context.Background(), 38.0301, -78.4795)
+			if err != nil {
+				t.Fatalf("ReverseGeocode() error = %v", err)
+			}
+			if got.URL.Path != tt.wantPath {
+				t.Errorf("path = %s, want %s", got.URL.Path, tt.wantPath)
+			}
+			for name, want := range tt.wantParams {
+				if value := got.URL.Query().Get(name); value != want {
+					t.Errorf("param %s = %q, want %q", name, value, want)
+				}
+			}
+			if result.Address != tt.wantAddress {
+				t.Errorf("Address = %+v, want %+v", result.Address, tt.wantAddress)
+			}
+			if !result.Address.InCity("Charlottesville") || !result.Address.InState("VA") {
+				t.Errorf("Address %+v not in Charlottesville, VA", result.Address)
+			}
+		})
+	}
+}
+
+func TestReverseGeocodeNothingThere(t *testing.T) {
+	srv := jsonServer(t, `{"error":"Unable to geocode"}`)
+	_, err := NewNominatim(&Client{BaseURL: srv.URL}).ReverseGeocode(context.Background(), 0, 0)
+	if !errors.Is(err, ErrNoResults) {
+		t.Errorf("ReverseGeocode() error = %v, want ErrNoResults", err)
+	}
+}
+
+func TestAddressMatching(t *testing.T) {
+	a := Address{City: "Richmond", County: "Madison County", State: "Kentucky"}
+	if a.InState("VA") || !a.InState("KY") || !a.InState("kentucky") {
+		t.Errorf("InState() wrong for %+v", a)
+	}
+	if !a.InCity("richmond") || !a.InCity("City of Richmond") || a.InCity("Charlottesville") || a.InCity("") {
+		t.Errorf("InCity() wrong for %+v", a)
+	}
+
+	if got := StateCode("Virginia"); got != "VA" {
+		t.Errorf("StateCode(Virginia) = %q, want VA", got)
+	}
+	if got := StateCode("ca"); got != "CA" {
+		t.Errorf("StateCode(ca) = %q, want CA", got)
+	}
+	if got := StateCode("Ontario"); got != "" {
+		t.Errorf("StateCode(Ontario) = %q, want empty", got)
+	}
+	if got := StateName("va"); got != "Virginia" {
+		t.Errorf("StateName(va) = %q, want Virginia", got)
+	}
+	if got := StateName("Ontario"); got != "" {
+		t.Errorf("StateName(Ontario) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,68 @@
+package geocode
+
+import "strings"
+
+// stateNames maps US state and DC postal codes to their names
+var stateNames = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas",
+	"CA": "California", "CO": "Colorado", "CT": "Connecticut", "DE": "Delaware",
+	"DC": "District of Columbia", "FL": "Florida", "GA": "Georgia", "HI": "Hawaii",
+	"ID": "Idaho", "IL": "Illinois", "IN": "Indiana", "IA": "Iowa",
+	"KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana", "ME": "Maine",
+	"MD": "Maryland", "MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota",
+	"MS": "Mississippi", "MO": "Missouri", "MT": "Montana", "NE": "Nebraska",
+	"NV": "Nevada", "NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico",
+	"NY": "New York", "NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio",
+	"OK": "Oklahoma", "OR": "Oregon", "PA": "Pennsylvania", "RI": "Rhode Island",
+	"SC": "South Carolina", "SD": "South Dakota", "TN": "Tennessee", "TX": "Texas",
+	"UT": "Utah", "VT": "Vermont", "VA": "Virginia", "WA": "Washington",
+	"WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+}
+
+// StateCode returns the postal code for a US state given by name or code,
+// or "" if it isn't one
+func StateCode(state string) string {
+	state = strings.TrimSpace(state)
+	if _, ok := stateNames[strings.ToUpper(state)]; ok {
+		return strings.ToUpper(state)
+	}
+	for code, name := range stateNames {
+		if strings.EqualFold(name, state) {
+			return code
+		}
+	}
+	return ""
+}
+
+// StateName returns the name of a US state given by name or code, or "" if
+// it isn't one
+func StateName(state string) string {
+	return stateNames[StateCode(state)]
+}
+
+// InState reports whether the address is in state, given by name or code
+func (a Address) InState(state string) bool {
+	code := StateCode(state)
+	return code != "" && StateCode(a.State) == code
+}
+
+// InCity reports whether the address is in city. Case, punctuation and a
+// leading "City of" are ignored, and a city named in the county, as with
+// Virginia's independent cities, also counts.
+func (a Address) InCity(city string) bool {
+	want := normalizeCity(city)
+	if want == "" {
+		return false
+	}
+	return normalizeCity(a.City) == want || normalizeCity(a.County) == want
+}
+
+// normalizeCity folds a city or county name for comparison
+func normalizeCity(name string) string {
+	name = NormalizeQuery(name)
+	name = strings.TrimPrefix(name, "city of ")
+	name = strings.TrimPrefix(name, "town of ")
+	name = strings.TrimSuffix(name, " city")
+	name = strings.TrimSuffix(name, " county")
+	return name
+}
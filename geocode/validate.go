@@ -0,0 +1,127 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultCandidates is how many matches Validated asks for
+const defaultCandidates = 5
+
+// Rejection is a candidate match that was passed over
+type Rejection struct {
+	Candidate Result
+	Reason    string
+}
+
+// Validated checks each candidate match from its geocoder against the state
+// and city in the query, so a "Richmond, VA" address isn't placed in
+// Richmond, Kentucky. Candidates outside the state are rejected. A
+// candidate in the right state but another city is only used when no
+// candidate is in the right city, and then comes back with a Warning. The
+// passed over candidates are listed in the result's Rejected.
+type Validated struct {
+	Geocoder Geocoder
+	// Candidates is how many matches to consider when the geocoder is a
+	// CandidateGeocoder; 0 means 5
+	Candidates int
+}
+
+// Validate wraps g so its results are checked against the query. Each
+// member of a Chain is wrapped separately, so that when every candidate
+// from one provider is rejected the next provider is tried.
+func Validate(g Geocoder) Geocoder {
+	if chain, ok := g.(Chain); ok {
+		validated := make(Chain, len(chain))
+		for i, member := range chain {
+			validated[i] = Validate(member)
+		}
+		return validated
+	}
+	return &Validated{Geocoder: g}
+}
+
+// Geocode returns the first candidate in the expected state and city
+func (v *Validated) Geocode(ctx context.Context, query Query) (Result, error) {
+	var candidates []Result
+	if cg, ok := v.Geocoder.(CandidateGeocoder); ok {
+		limit := v.Candidates
+		if limit <= 0 {
+			limit = defaultCandidates
+		}
+		results, err := cg.GeocodeCandidates(ctx, query, limit)
+		if err != nil {
+			return Result{Error: err}, err
+		}
+		candidates = results
+	} else {
+		result, err := v.Geocoder.Geocode(ctx, query)
+		if err != nil {
+			return result, err
+		}
+		candidates = []Result{result}
+	}
+
+	var rejected []Rejection
+	var wrongCity []Rejection
+	for _, candidate := range candidates {
+		if reason := checkState(query, candidate); reason != "" {
+			rejected = append(rejected, Rejection{Candidate: candidate, Reason: reason})
+			continue
+		}
+		if reason := checkCity(query, candidate); reason != "" {
+			wrongCity = append(wrongCity, Rejection{Candidate: candidate, Reason: reason})
+			continue
+		}
+		candidate.Rejected = append(rejected, wrongCity...)
+		return candidate, nil
+	}
+
+	// Settle for the right state when no candidate is in the right city
+	if len(wrongCity) > 0 {
+		result := wrongCity[0].Candidate
+		result.Warning = wrongCity[0].Reason
+		result.Rejected = append(rejected, wrongCity[1:]...)
+		return result, nil
+	}
+	return Result{Error: ErrNoResults, Rejected: rejected}, ErrNoResults
+}
+
+// checkState explains why result is outside the query's state, or returns
+// an empty string if it isn't or the query has no state
+func checkState(query Query, result Result) string {
+	state := StateCode(query.State)
+	if state == "" || result.Coords == nil {
+		return ""
+	}
+	if result.Address.State != "" && !result.Address.InState(state) {
+		return fmt.Sprintf("in %s, not %s", result.Address.State, state)
+	}
+	// The outline where there is one, since a box takes in bits of the
+	// neighboring states
+	near, ok := nearOutline(state, *result.Coords)
+	if !ok {
+		box, hasBox := StateBounds(state)
+		near = !hasBox || box.Contains(*result.Coords)
+	}
+	if !near {
+		return fmt.Sprintf("%.4f, %.4f is outside %s", result.Coords.Latitude, result.Coords.Longitude, state)
+	}
+	return ""
+}
+
+// checkCity explains why result is outside the query's city, or returns an
+// empty string if it isn't or either side doesn't name a city
+func checkCity(query Query, result Result) string {
+	if query.City == "" || (result.Address.City == "" && result.Address.County == "") {
+		return ""
+	}
+	if result.Address.InCity(query.City) {
+		return ""
+	}
+	found := result.Address.City
+	if found == "" {
+		found = result.Address.County
+	}
+	return fmt.Sprintf("in %s, not %s", found, query.City)
+}
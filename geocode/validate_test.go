@@ -0,0 +1,142 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubCandidates returns canned candidates
+type stubCandidates struct {
+	stubGeocoder
+	candidates []Result
+	limit      int
+}
+
+func (s *stubCandidates) GeocodeCandidates(ctx context.Context, query Query, limit int) ([]Result, error) {
+	s.limit = limit
+	return s.candidates, nil
+}
+
+// candidate builds a result at lat, lon in city and state
+func candidate(lat, lon float64, city, state string) Result {
+	return Result{
+		Coords:      &Coordinates{Latitude: lat, Longitude: lon},
+		DisplayName: city + ", " + state,
+		Address:     Address{City: city, State: state},
+	}
+}
+
+func TestValidated(t *testing.T) {
+	richmondKY := candidate(37.7479, -84.2947, "Richmond", "Kentucky")
+	richmondVA := candidate(37.5407, -77.4360, "Richmond", "Virginia")
+	henrico := candidate(37.5585, -77.4616, "Henrico", "Virginia")
+	noState := candidate(37.7479, -84.2947, "Richmond", "")
+	martinsburg := candidate(39.4562, -77.9639, "Richmond", "")
+
+	query := Query{Street: "1 Main St", City: "Richmond", State: "VA"}
+
+	tests := []struct {
+		name         string
+		candidates   []Result
+		wantName     string
+		wantWarning  bool
+		wantRejected int
+		wantErr      error
+	}{
+		{"first is right", []Result{richmondVA, richmondKY}, "Richmond, Virginia", false, 0, nil},
+		{"skips the wrong state", []Result{richmondKY, richmondVA}, "Richmond, Virginia", false, 1, nil},
+		{"checks bounds without a state", []Result{noState, richmondVA}, "Richmond, Virginia", false, 1, nil},
+		{"checks the outline without a state", []Result{martinsburg, richmondVA}, "Richmond, Virginia", false, 1, nil},
+		{"prefers the right city", []Result{henrico, richmondVA}, "Richmond, Virginia", false, 1, nil},
+		{"settles for another city", []Result{richmondKY, henrico}, "Henrico, Virginia", true, 1, nil},
+		{"rejects everything", []Result{richmondKY, noState}, "", false, 2, ErrNoResults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubCandidates{candidates: tt.candidates}
+			result, err := Validate(stub).Geocode(context.Background(), query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Geocode() error = %v, want %v", err, tt.wantErr)
+			}
+			if stub.limit != defaultCandidates {
+				t.Errorf("asked for %d candidates, want %d", stub.limit, defaultCandidates)
+			}
+			if result.DisplayName != tt.wantName {
+				t.Errorf("DisplayName = %q, want %q", result.DisplayName, tt.wantName)
+			}
+			if (result.Warning != "") != tt.wantWarning {
+				t.Errorf("Warning = %q, want warning %v", result.Warning, tt.wantWarning)
+			}
+			if len(result.Rejected) != tt.wantRejected {
+				t.Errorf("Rejected = %+v, want %d", result.Rejected, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestValidateChain(t *testing.T) {
+	// The first provider only knows the wrong Richmond
+	wrong := &stubGeocoder{result: candidate(37.7479, -84.2947, "Richmond", "Kentucky")}
+	right := &stubGeocoder{result: candidate(37.5407, -77.4360, "Richmond", "Virginia")}
+
+	result, err := Validate(Chain{wrong, right}).Geocode(context.Background(), Query{City: "Richmond", State: "Virginia"})
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if result.Address.State != "Virginia" || right.calls != 1 {
+		t.Errorf("Geocode() = %+v, want the second provider's answer", result)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Reason != "in Kentucky, not VA" {
+		t.Errorf("Rejected = %+v, want the Kentucky candidate", result.Rejected)
+	}
+}
+
+func TestStateBounds(t *testing.T) {
+	if len(stateBounds) != len(stateNames) {
+		t.Errorf("have bounds for %d states, want %d", len(stateBounds), len(stateNames))
+	}
+	for code, box := range stateBounds {
+		if box.South >= box.North || box.West >= box.East {
+			t.Errorf("%s bounds %+v are inside out", code, box)
+		}
+	}
+
+	va, ok := StateBounds("Virginia")
+	if !ok {
+		t.Fatal("StateBounds(Virginia) not found")
+	}
+	if !va.Contains(Coordinates{Latitude: 38.0301, Longitude: -78.4795}) {
+		t.Error("Virginia bounds don't contain Charlottesville")
+	}
+	if va.Contains(Coordinates{Latitude: 37.7479, Longitude: -84.2947}) {
+		t.Error("Virginia bounds contain Richmond, KY")
+	}
+}
+
+func TestNominatimCandidates(t *testing.T) {
+	var limit string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit = r.URL.Query().Get("limit")
+		w.Write([]byte(`[
+			{"lat":"37.7479","lon":"-84.2947","display_name":"Richmond, Kentucky","address":{"city":"Richmond","state":"Kentucky"}},
+			{"lat":"37.5407","lon":"-77.4360","display_name":"Richmond, Virginia","address":{"city":"Richmond","state":"Virginia"}}
+		]`))
+	}))
+	defer srv.Close()
+
+	result, err := Validate(NewNominatim(&Client{BaseURL: srv.URL})).Geocode(context.Background(), FreeText("Richmond"))
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if limit != "5" {
+		t.Errorf("limit = %q, want 5", limit)
+	}
+	// Without a state in the query there is nothing to reject
+	if result.DisplayName != "Richmond, Kentucky" {
+		t.Errorf("DisplayName = %q, want the first candidate", result.DisplayName)
+	}
+}
@@ -2,7 +2,10 @@ module github.com/chicks-net/quilt-shop-proximity/merge
 
 go 1.21
 
-require modernc.org/sqlite v1.28.0
+require (
+	github.com/chicks-net/quilt-shop-proximity/proximity v0.0.0
+	modernc.org/sqlite v1.28.0
+)
 
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -23,3 +26,5 @@ require (
 	modernc.org/strutil v1.1.3 // indirect
 	modernc.org/token v1.0.1 // indirect
 )
+
+replace github.com/chicks-net/quilt-shop-proximity/proximity => ../proximity
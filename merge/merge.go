@@ -0,0 +1,319 @@
+// Package merge combines the state databases into the one the app ships,
+// keeping the shops that have coordinates and indexing them for proximity
+// search
+package merge
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultGeohashPrecision gives cells about 5 meters across
+const DefaultGeohashPrecision = 9
+
+// Input is a state database to merge
+type Input struct {
+	Path string
+	// State and Source are used when the database doesn't record its own in
+	// its metadata table, as those scraped before it was added don't
+	State  string
+	Source string
+}
+
+// Summary is what Merge took from one input
+type Summary struct {
+	Path   string
+	Source string
+	State  string
+	// Type, URL and FetchedAt are the input's record of its list, if any
+	Type      string
+	URL       string
+	FetchedAt string
+	// Merged is the shops with coordinates, and Skipped those without
+	Merged  int
+	Skipped int
+}
+
+// Shop represents a quilt shop record
+type Shop struct {
+	Name               string
+	Address            sql.NullString
+	City               string
+	Phone              sql.NullString
+	Email              sql.NullString
+	Website            sql.NullString
+	Latitude           float64
+	Longitude          float64
+	CreatedAt          string
+	GeocodeAttemptedAt sql.NullString
+	GeocodeMatchType   sql.NullString
+}
+
+// Merge replaces the database at path with the shops from each input that
+// have coordinates, describes the result and the build in its metadata
+// table, and returns a summary of each input
+func Merge(path string, inputs []Input, geohashPrecision int, build Build) ([]Summary, error) {
+	if geohashPrecision < 1 || geohashPrecision > proximity.MaxGeohashPrecision {
+		return nil, fmt.Errorf("geohash precision must be between 1 and %d", proximity.MaxGeohashPrecision)
+	}
+
+	// Remove existing merged database if it exists
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove existing database: %w", err)
+	}
+
+	// Create new merged database
+	mergedDB, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merged database: %w", err)
+	}
+	defer mergedDB.Close()
+
+	// Create schema
+	if err := createSchema(mergedDB); err != nil {
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	var summaries []Summary
+	for _, input := range inputs {
+		summary, err := mergeStateShops(mergedDB, input, geohashPrecision)
+		if err != nil {
+			return summaries, fmt.Errorf("failed to merge %s: %w", input.Path, err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := writeMetadata(mergedDB, build, summaries, geohashPrecision); err != nil {
+		return summaries, fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	// VACUUM to optimize database
+	if _, err := mergedDB.Exec("VACUUM"); err != nil {
+		return summaries, fmt.Errorf("failed to VACUUM database: %w", err)
+	}
+	return summaries, nil
+}
+
+func createSchema(db *sql.DB) error {
+	schema := `
+		CREATE TABLE quilt_shops (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			address TEXT,
+			city TEXT NOT NULL,
+			state TEXT NOT NULL,
+			phone TEXT,
+			email TEXT,
+			website TEXT,
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			geocode_attempted_at DATETIME,
+			geocode_match_type TEXT,
+			geohash TEXT
+		);
+
+		CREATE INDEX idx_city ON quilt_shops(city);
+		CREATE INDEX idx_state ON quilt_shops(state);
+		CREATE INDEX idx_coordinates ON quilt_shops(latitude, longitude);
+		CREATE INDEX idx_geohash ON quilt_shops(geohash);
+
+		-- What the database holds and how it was built, for the app to read
+		CREATE TABLE metadata (
+			key TEXT PRIMARY KEY,
+			value TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		-- R*Tree spatial index for two-dimensional box queries, keyed by shop id
+		CREATE VIRTUAL TABLE quilt_shops_rtree USING rtree(
+			id,
+			min_lat, max_lat,
+			min_lon, max_lon
+		);
+	`
+	_, err := db.Exec(schema)
+	return err
+}
+
+func mergeStateShops(mergedDB *sql.DB, input Input, geohashPrecision int) (Summary, error) {
+	summary := Summary{Path: input.Path, Source: input.Source, State: input.State}
+
+	// sql.Open would quietly create an empty database
+	if _, err := os.Stat(input.Path); err != nil {
+		return summary, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Open source database
+	sourceDB, err := sql.Open("sqlite", input.Path)
+	if err != nil {
+		return summary, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer sourceDB.Close()
+
+	// The database's own record of its state and source comes first
+	info, err := readInfo(sourceDB)
+	if err != nil {
+		return summary, err
+	}
+	if info["state"] != "" {
+		summary.State = info["state"]
+	}
+	if info["source"] != "" {
+		summary.Source = info["source"]
+	}
+	summary.Type, summary.URL, summary.FetchedAt = info["type"], info["url"], info["fetched_at"]
+	if summary.State == "" {
+		return summary, fmt.Errorf("no state recorded in its metadata, scrape it again")
+	}
+	state := summary.State
+
+	if err := sourceDB.QueryRow("SELECT COUNT(*) FROM quilt_shops WHERE latitude IS NULL OR longitude IS NULL").Scan(&summary.Skipped); err != nil {
+		return summary, fmt.Errorf("failed to count %s shops: %w", state, err)
+	}
+
+	// Older state databases lack some columns, so select NULL in their place
+	website, err := optionalColumn(sourceDB, "website")
+	if err != nil {
+		return summary, err
+	}
+	matchType, err := optionalColumn(sourceDB, "geocode_match_type")
+	if err != nil {
+		return summary, err
+	}
+
+	// Query shops with coordinates only
+	query := fmt.Sprintf(`
+		SELECT name, address, city, phone, email, %s, latitude, longitude, created_at, geocode_attempted_at, %s
+		FROM quilt_shops
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+	`, website, matchType)
+
+	rows, err := sourceDB.Query(query)
+	if err != nil {
+		return summary, fmt.Errorf("failed to query %s shops: %w", state, err)
+	}
+	defer rows.Close()
+
+	// Prepare insert statement
+	insertStmt, err := mergedDB.Prepare(`
+		INSERT INTO quilt_shops (name, address, city, state, phone, email, website, latitude, longitude, created_at, geocode_attempted_at, geocode_match_type, geohash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return summary, fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer insertStmt.Close()
+
+	// Prepare spatial index insert; each shop is a point, so min equals max
+	rtreeStmt, err := mergedDB.Prepare(`
+		INSERT INTO quilt_shops_rtree (id, min_lat, max_lat, min_lon, max_lon)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return summary, fmt.Errorf("failed to prepare spatial index statement: %w", err)
+	}
+	defer rtreeStmt.Close()
+
+	// Insert shops
+	for rows.Next() {
+		var shop Shop
+		err := rows.Scan(
+			&shop.Name,
+			&shop.Address,
+			&shop.City,
+			&shop.Phone,
+			&shop.Email,
+			&shop.Website,
+			&shop.Latitude,
+			&shop.Longitude,
+			&shop.CreatedAt,
+			&shop.GeocodeAttemptedAt,
+			&shop.GeocodeMatchType,
+		)
+		if err != nil {
+			return summary, fmt.Errorf("failed to scan shop: %w", err)
+		}
+
+		result, err := insertStmt.Exec(
+			shop.Name,
+			shop.Address,
+			shop.City,
+			state,
+			shop.Phone,
+			shop.Email,
+			shop.Website,
+			shop.Latitude,
+			shop.Longitude,
+			shop.CreatedAt,
+			shop.GeocodeAttemptedAt,
+			shop.GeocodeMatchType,
+			proximity.Geohash(proximity.Point{Latitude: shop.Latitude, Longitude: shop.Longitude}, geohashPrecision),
+		)
+		if err != nil {
+			return summary, fmt.Errorf("failed to insert shop: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return summary, fmt.Errorf("failed to get shop id: %w", err)
+		}
+		if _, err := rtreeStmt.Exec(id, shop.Latitude, shop.Latitude, shop.Longitude, shop.Longitude); err != nil {
+			return summary, fmt.Errorf("failed to index shop: %w", err)
+		}
+		summary.Merged++
+	}
+
+	if err := rows.Err(); err != nil {
+		return summary, fmt.Errorf("error iterating %s shops: %w", state, err)
+	}
+
+	return summary, nil
+}
+
+// optionalColumn returns name if the source quilt_shops table has that
+// column, or a NULL placeholder if it doesn't
+func optionalColumn(sourceDB *sql.DB, name string) (string, error) {
+	var exists bool
+	err := sourceDB.QueryRow("SELECT COUNT(*) FROM pragma_table_info('quilt_shops') WHERE name = ?", name).Scan(&exists)
+	if err != nil {
+		return "", fmt.Errorf("failed to check schema: %w", err)
+	}
+	if !exists {
+		return "NULL AS " + name, nil
+	}
+	return name, nil
+}
+
+// readInfo returns the key and value pairs of the metadata table the
+// scraper writes in a state database, or none if it has no such table
+func readInfo(sourceDB *sql.DB) (map[string]string, error) {
+	info := map[string]string{}
+	var tables int
+	err := sourceDB.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'metadata'").Scan(&tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema: %w", err)
+	}
+	if tables == 0 {
+		return info, nil
+	}
+
+	rows, err := sourceDB.Query("SELECT key, value FROM metadata")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to read metadata: %w", err)
+		}
+		info[key] = value.String
+	}
+	return info, rows.Err()
+}
@@ -0,0 +1,151 @@
+package merge
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeStateDB writes a state database in the scrapers' schema. Older
+// databases have no website or match type columns, like the first
+// California scrape, and no metadata; newer ones record info there.
+func writeStateDB(t *testing.T, path string, info map[string]string, shops [][]interface{}) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema := `CREATE TABLE quilt_shops (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL, address TEXT, city TEXT NOT NULL, phone TEXT, email TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		latitude REAL, longitude REAL, geocode_attempted_at DATETIME`
+	if info != nil {
+		schema += ", website TEXT, geocode_match_type TEXT"
+	}
+	if _, err := db.Exec(schema + ")"); err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		if _, err := db.Exec("CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range info {
+			if _, err := db.Exec("INSERT INTO metadata (key, value) VALUES (?, ?)", key, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, shop := range shops {
+		if _, err := db.Exec("INSERT INTO quilt_shops (name, city, latitude, longitude) VALUES (?, ?, ?, ?)", shop...); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.db")
+	vaPath := filepath.Join(dir, "va.db")
+	orPath := filepath.Join(dir, "or.db")
+	writeStateDB(t, caPath, nil, [][]interface{}{
+		{"Mel's Sewing & Fabric Center", "anaheim", 33.85, -117.94},
+		{"Not Geocoded", "anaheim", nil, nil},
+	})
+	writeStateDB(t, vaPath, map[string]string{"state": "VA", "source": "vcq"}, [][]interface{}{
+		{"Les Fabriques", "Charlottesville", 38.0293, -78.4767},
+		{"Sew Classic", "Mount Crawford", 38.35, -78.94},
+	})
+	writeStateDB(t, orPath, map[string]string{"state": "OR", "source": "guild", "type": "csv",
+		"url": "https://example.com/oregon.csv", "fetched_at": "2025-03-01T12:00:00Z"}, [][]interface{}{
+		{"Stitchin' Post", "Sisters", 44.29, -121.55},
+	})
+
+	path := filepath.Join(dir, "merged.db")
+	inputs := []Input{
+		{Path: caPath, State: "CA", Source: "ribbiter"},
+		// The database's own metadata wins over what the caller expects
+		{Path: vaPath, State: "MD"},
+		{Path: orPath},
+	}
+	build := Build{Commit: "abc123", Generator: "quiltshops test", Time: time.Date(2025, 3, 2, 8, 30, 0, 0, time.UTC)}
+	summaries, err := Merge(path, inputs, DefaultGeohashPrecision, build)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	want := []Summary{
+		{Path: caPath, Source: "ribbiter", State: "CA", Merged: 1, Skipped: 1},
+		{Path: vaPath, Source: "vcq", State: "VA", Merged: 2},
+		{Path: orPath, Source: "guild", State: "OR", Type: "csv", URL: "https://example.com/oregon.csv", FetchedAt: "2025-03-01T12:00:00Z", Merged: 1},
+	}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("Merge() = %+v, want %+v", summaries, want)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var state, geohash string
+	err = db.QueryRow("SELECT state, geohash FROM quilt_shops WHERE name = 'Les Fabriques'").Scan(&state, &geohash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "VA" || len(geohash) != DefaultGeohashPrecision {
+		t.Errorf("Les Fabriques state = %q, geohash = %q", state, geohash)
+	}
+
+	var indexed int
+	if err := db.QueryRow("SELECT COUNT(*) FROM quilt_shops_rtree").Scan(&indexed); err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 4 {
+		t.Errorf("spatial index has %d shops, want 4", indexed)
+	}
+
+	metadata, err := readInfo(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMetadata := map[string]string{
+		"schema_version": "1",
+		"version":        "2025.03.02",
+		"built_at":       "2025-03-02T08:30:00Z",
+		"total_shops":    "4",
+		"states":         `{"CA":1,"OR":1,"VA":2}`,
+		"sources": `[{"source":"ribbiter","state":"CA","shops":1},{"source":"vcq","state":"VA","shops":2},` +
+			`{"source":"guild","type":"csv","state":"OR","url":"https://example.com/oregon.csv","fetched_at":"2025-03-01T12:00:00Z","shops":1}]`,
+		"git_commit":        "abc123",
+		"generator":         "quiltshops test",
+		"geohash_precision": "9",
+	}
+	if !reflect.DeepEqual(metadata, wantMetadata) {
+		t.Errorf("metadata = %v, want %v", metadata, wantMetadata)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "merged.db")
+
+	if _, err := Merge(path, nil, 0, Build{}); err == nil {
+		t.Error("Merge() error = nil, want an error for geohash precision 0")
+	}
+	if _, err := Merge(path, []Input{{Path: filepath.Join(dir, "missing.db"), State: "VA"}}, DefaultGeohashPrecision, Build{}); err == nil {
+		t.Error("Merge() error = nil, want an error for a missing state database")
+	}
+
+	// Without metadata or a state from the caller there's no telling
+	unknown := filepath.Join(dir, "unknown.db")
+	writeStateDB(t, unknown, nil, nil)
+	if _, err := Merge(path, []Input{{Path: unknown}}, DefaultGeohashPrecision, Build{}); err == nil {
+		t.Error("Merge() error = nil, want an error for a database with no state")
+	}
+}
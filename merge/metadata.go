@@ -0,0 +1,102 @@
+package merge
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SchemaVersion is the version of the merged database's tables, raised
+// when they change in a way the app has to know about
+const SchemaVersion = 1
+
+// Build describes a merge run for the metadata table
+type Build struct {
+	// DataVersion names this edition of the data; empty uses the build date,
+	// like 2025.03.01
+	DataVersion string
+	// Commit is the git commit the merge was built from
+	Commit string
+	// Generator is the program that built the database and its version
+	Generator string
+	// Time is when the merge ran; zero means now
+	Time time.Time
+}
+
+// SourceInfo is the metadata entry for one merged state database
+type SourceInfo struct {
+	Source    string `json:"source"`
+	Type      string `json:"type,omitempty"`
+	State     string `json:"state"`
+	URL       string `json:"url,omitempty"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	Shops     int    `json:"shops"`
+}
+
+// writeMetadata fills the metadata table. Counts are plain numbers and the
+// per-state and per-source details JSON, which the app can parse.
+func writeMetadata(db *sql.DB, build Build, summaries []Summary, geohashPrecision int) error {
+	if build.Time.IsZero() {
+		build.Time = time.Now()
+	}
+	if build.DataVersion == "" {
+		build.DataVersion = build.Time.UTC().Format("2006.01.02")
+	}
+
+	total := 0
+	states := map[string]int{}
+	sources := make([]SourceInfo, len(summaries))
+	for i, s := range summaries {
+		total += s.Merged
+		states[s.State] += s.Merged
+		sources[i] = SourceInfo{
+			Source:    s.Source,
+			Type:      s.Type,
+			State:     s.State,
+			URL:       s.URL,
+			FetchedAt: s.FetchedAt,
+			Shops:     s.Merged,
+		}
+	}
+	statesJSON, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	sourcesJSON, err := json.Marshal(sources)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{
+		"schema_version": strconv.Itoa(SchemaVersion),
+		"version":        build.DataVersion,
+		"built_at":       build.Time.UTC().Format(time.RFC3339),
+		"total_shops":    strconv.Itoa(total),
+		"states":         string(statesJSON),
+		"sources":        string(sourcesJSON),
+		"git_commit":     build.Commit,
+		"generator":      build.Generator,
+		// Neighborhood can't look up cells finer than the stored geohashes
+		"geohash_precision": strconv.Itoa(geohashPrecision),
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	stmt, err := db.Prepare("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, key := range keys {
+		if _, err := stmt.Exec(key, values[key]); err != nil {
+			return fmt.Errorf("failed to record %s: %w", key, err)
+		}
+	}
+	return nil
+}
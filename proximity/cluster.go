@@ -0,0 +1,176 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Cluster is a group of shops found by DBSCAN
+type Cluster struct {
+	// ID numbers clusters from 1, largest first
+	ID    int
+	Shops []Shop
+	// Centroid is the middle of the shops on the globe
+	Centroid Point
+	// Radius is how far the farthest shop is from Centroid
+	Radius Distance
+}
+
+// DBSCAN groups shops into clusters of dense areas. A shop with at least
+// minPoints shops within epsilon, counting itself, is a core shop; core
+// shops within epsilon of each other share a cluster, along with every shop
+// within epsilon of one of them. The rest are returned as noise.
+func DBSCAN(shops []Shop, epsilon Distance, minPoints int) (clusters []Cluster, noise []Shop) {
+	// Sorting by latitude bounds each neighbor search to a band of the list
+	sorted := append([]Shop(nil), shops...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Latitude != sorted[j].Latitude {
+			return sorted[i].Latitude < sorted[j].Latitude
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	band := degrees(float64(epsilon / earthRadius))
+	neighbors := func(i int) []int {
+		lo := sort.Search(len(sorted), func(j int) bool {
+			return sorted[j].Latitude >= sorted[i].Latitude-band
+		})
+		var found []int
+		for j := lo; j < len(sorted) && sorted[j].Latitude <= sorted[i].Latitude+band; j++ {
+			if Haversine(sorted[i].Point(), sorted[j].Point()) <= epsilon {
+				found = append(found, j)
+			}
+		}
+		return found
+	}
+
+	// Visit shops in id order so the clusters come out the same every run
+	order := make([]int, len(sorted))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return sorted[order[a]].ID < sorted[order[b]].ID })
+
+	const unvisited, noiseLabel = 0, -1
+	labels := make([]int, len(sorted))
+	groups := 0
+	for _, i := range order {
+		if labels[i] != unvisited {
+			continue
+		}
+		seeds := neighbors(i)
+		if len(seeds) < minPoints {
+			labels[i] = noiseLabel
+			continue
+		}
+
+		groups++
+		labels[i] = groups
+		for k := 0; k < len(seeds); k++ {
+			j := seeds[k]
+			if labels[j] == noiseLabel {
+				// A noise shop within reach of a core shop is a border shop
+				labels[j] = groups
+			}
+			if labels[j] != unvisited {
+				continue
+			}
+			labels[j] = groups
+			if more := neighbors(j); len(more) >= minPoints {
+				seeds = append(seeds, more...)
+			}
+		}
+	}
+
+	members := make([][]Shop, groups)
+	for i, label := range labels {
+		if label == noiseLabel {
+			noise = append(noise, sorted[i])
+		} else {
+			members[label-1] = append(members[label-1], sorted[i])
+		}
+	}
+	for _, group := range members {
+		sort.Slice(group, func(a, b int) bool { return group[a].ID < group[b].ID })
+		middle := centroid(group)
+		var radius Distance
+		for _, s := range group {
+			radius = max(radius, Haversine(middle, s.Point()))
+		}
+		clusters = append(clusters, Cluster{Shops: group, Centroid: middle, Radius: radius})
+	}
+	sort.SliceStable(clusters, func(a, b int) bool { return len(clusters[a].Shops) > len(clusters[b].Shops) })
+	for i := range clusters {
+		clusters[i].ID = i + 1
+	}
+	sort.Slice(noise, func(a, b int) bool { return noise[a].ID < noise[b].ID })
+	return clusters, noise
+}
+
+// centroid averages the shops as vectors from the center of the Earth, which
+// works across the antimeridian
+func centroid(shops []Shop) Point {
+	var x, y, z float64
+	for _, s := range shops {
+		lat, lon := radians(s.Latitude), radians(s.Longitude)
+		x += math.Cos(lat) * math.Cos(lon)
+		y += math.Cos(lat) * math.Sin(lon)
+		z += math.Sin(lat)
+	}
+	return Point{
+		Latitude:  degrees(math.Atan2(z, math.Hypot(x, y))),
+		Longitude: degrees(math.Atan2(y, x)),
+	}
+}
+
+// AllShops returns every shop in the database, by id
+func (d *DB) AllShops(ctx context.Context) ([]Shop, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+d.shopColumns()+` FROM quilt_shops ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shops: %w", err)
+	}
+	defer rows.Close()
+	return scanShops(rows)
+}
+
+// SaveClusters replaces the shop_clusters side table with the cluster of
+// each clustered shop. Noise shops have no row.
+func (d *DB) SaveClusters(ctx context.Context, clusters []Cluster) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS shop_clusters (
+			shop_id INTEGER PRIMARY KEY REFERENCES quilt_shops(id),
+			cluster_id INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create shop_clusters table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM shop_clusters"); err != nil {
+		return fmt.Errorf("failed to clear shop_clusters: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO shop_clusters (shop_id, cluster_id) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, c := range clusters {
+		for _, s := range c.Shops {
+			if _, err := stmt.ExecContext(ctx, s.ID, c.ID); err != nil {
+				return fmt.Errorf("failed to save cluster of shop %d: %w", s.ID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestDBSCAN(t *testing.T) {
+	tests := []struct {
+		name      string
+		epsilon   Distance
+		minPoints int
+		want      [][]int
+		wantNoise []int
+	}{
+		// Charlottesville reaches Richmond and Harrisonburg, which only
+		// reach it, so they join as border shops
+		{"core and border shops", 70 * Mile, 3, [][]int{{1, 2, 3}}, []int{4, 5, 6}},
+		{"pairs", 40 * Mile, 2, [][]int{{1, 3}}, []int{2, 4, 5, 6}},
+		{"chained through Richmond", 90 * Mile, 2, [][]int{{1, 2, 3, 4}}, []int{5, 6}},
+		{"largest first", 400 * Mile, 2, [][]int{{1, 2, 3, 4}, {5, 6}}, nil},
+		{"too sparse", 40 * Mile, 3, nil, []int{1, 2, 3, 4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters, noise := DBSCAN(testShops, tt.epsilon, tt.minPoints)
+			var got [][]int
+			for i, c := range clusters {
+				if c.ID != i+1 {
+					t.Errorf("cluster %d has ID %d", i, c.ID)
+				}
+				got = append(got, ids(c.Shops))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("DBSCAN() clusters = %v, want %v", got, tt.want)
+			}
+			if gotNoise := ids(noise); !slices.Equal(gotNoise, tt.wantNoise) {
+				t.Errorf("DBSCAN() noise = %v, want %v", gotNoise, tt.wantNoise)
+			}
+		})
+	}
+}
+
+func TestDBSCANCentroid(t *testing.T) {
+	shops := []Shop{
+		{ID: 1, Latitude: 10, Longitude: 179.9},
+		{ID: 2, Latitude: 10, Longitude: -179.9},
+	}
+	clusters, _ := DBSCAN(shops, 50*Mile, 2)
+	if len(clusters) != 1 {
+		t.Fatalf("DBSCAN() found %d clusters, want 1", len(clusters))
+	}
+
+	c := clusters[0]
+	if lon := c.Centroid.Longitude; lon > -179.99 && lon < 179.99 {
+		t.Errorf("centroid across the antimeridian = %s, want longitude 180", c.Centroid)
+	}
+	if half := Haversine(shops[0].Point(), shops[1].Point()) / 2; c.Radius < half*0.99 || c.Radius > half*1.01 {
+		t.Errorf("Radius = %.0f m, want about %.0f", c.Radius, half)
+	}
+}
+
+func TestSaveClusters(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	shops, err := db.AllShops(ctx)
+	if err != nil {
+		t.Fatalf("AllShops() error = %v", err)
+	}
+	if len(shops) != len(testShops) {
+		t.Fatalf("AllShops() returned %d shops, want %d", len(shops), len(testShops))
+	}
+
+	// Saving again replaces the earlier clusters
+	for _, epsilon := range []Distance{400 * Mile, 70 * Mile} {
+		clusters, _ := DBSCAN(shops, epsilon, 2)
+		if err := db.SaveClusters(ctx, clusters); err != nil {
+			t.Fatalf("SaveClusters() error = %v", err)
+		}
+	}
+
+	rows, err := db.db.Query("SELECT shop_id, cluster_id FROM shop_clusters ORDER BY shop_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := map[int]int{}
+	for rows.Next() {
+		var shop, cluster int
+		if err := rows.Scan(&shop, &cluster); err != nil {
+			t.Fatal(err)
+		}
+		got[shop] = cluster
+	}
+	want := map[int]int{1: 1, 2: 1, 3: 1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("shop_clusters = %v, want %v", got, want)
+	}
+}
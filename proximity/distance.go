@@ -0,0 +1,128 @@
+package proximity
+
+import (
+	"fmt"
+	"math"
+)
+
+// earthRadius is the mean radius of the Earth
+const earthRadius = 6371008.8 * Meter
+
+// Point is a latitude and longitude in degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// String formats the point as "lat, lon"
+func (p Point) String() string {
+	return fmt.Sprintf("%.5f, %.5f", p.Latitude, p.Longitude)
+}
+
+// Distance is a length along the Earth's surface, stored in meters
+type Distance float64
+
+// Common distances
+const (
+	Meter     Distance = 1
+	Kilometer Distance = 1000
+	Mile      Distance = 1609.344
+)
+
+// Miles returns the distance in miles
+func (d Distance) Miles() float64 {
+	return float64(d / Mile)
+}
+
+// Kilometers returns the distance in kilometers
+func (d Distance) Kilometers() float64 {
+	return float64(d / Kilometer)
+}
+
+// radians converts degrees to radians
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// degrees converts radians to degrees
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// Haversine returns the great-circle distance between two points
+func Haversine(a, b Point) Distance {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * Distance(math.Asin(math.Min(1, math.Sqrt(h))))
+}
+
+// Bearing returns the initial compass bearing from a to b in degrees, with
+// 0 for north and 90 for east
+func Bearing(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLon := radians(b.Longitude - a.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// destination returns the point reached by going distance from start on
+// the initial compass bearing
+func destination(start Point, bearing float64, distance Distance) Point {
+	lat1, lon1 := radians(start.Latitude), radians(start.Longitude)
+	angle := float64(distance / earthRadius)
+	theta := radians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angle) + math.Cos(lat1)*math.Sin(angle)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(angle)*math.Cos(lat1),
+		math.Cos(angle)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{
+		Latitude:  degrees(lat2),
+		Longitude: math.Mod(degrees(lon2)+540, 360) - 180,
+	}
+}
+
+// compassPoints are the 8 principal winds, starting from north
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// CompassPoint names the nearest of the 8 compass directions to a bearing
+func CompassPoint(bearing float64) string {
+	i := int(math.Round(math.Mod(bearing+360, 360)/45)) % len(compassPoints)
+	return compassPoints[i]
+}
+
+// Box is a latitude and longitude range
+type Box struct {
+	South float64
+	North float64
+	West  float64
+	East  float64
+}
+
+// BoxAround returns a box containing every point within radius of center.
+// Near the poles or the antimeridian it covers all longitudes.
+func BoxAround(center Point, radius Distance) Box {
+	dLat := degrees(float64(radius / earthRadius))
+	box := Box{
+		South: math.Max(center.Latitude-dLat, -90),
+		North: math.Min(center.Latitude+dLat, 90),
+		West:  -180,
+		East:  180,
+	}
+
+	// The widest longitude span is at the points where the circle touches
+	// its meridians, which is asin(sin(r) / cos(lat)) either side
+	angle := float64(radius / earthRadius)
+	if x := math.Sin(angle) / math.Cos(radians(center.Latitude)); angle < math.Pi/2 && box.South > -90 && box.North < 90 && x < 1 {
+		dLon := degrees(math.Asin(x))
+		if west, east := center.Longitude-dLon, center.Longitude+dLon; west >= -180 && east <= 180 {
+			box.West, box.East = west, east
+		}
+	}
+	return box
+}
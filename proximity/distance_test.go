@@ -0,0 +1,84 @@
+package proximity
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	charlottesville = Point{Latitude: 38.0293, Longitude: -78.4767}
+	richmond        = Point{Latitude: 37.5407, Longitude: -77.4360}
+	losAngeles      = Point{Latitude: 34.0522, Longitude: -118.2437}
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      Point
+		wantMiles float64
+	}{
+		{"same point", charlottesville, charlottesville, 0},
+		{"Charlottesville to Richmond", charlottesville, richmond, 66.1},
+		{"across the country", charlottesville, losAngeles, 2226},
+		{"one degree of latitude", Point{0, 0}, Point{1, 0}, 69.09},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Haversine(tt.a, tt.b).Miles()
+			if math.Abs(got-tt.wantMiles) > tt.wantMiles*0.01+0.01 {
+				t.Errorf("Haversine() = %.1f mi, want %.1f", got, tt.wantMiles)
+			}
+			if back := Haversine(tt.b, tt.a).Miles(); math.Abs(back-got) > 1e-9 {
+				t.Errorf("Haversine() not symmetric: %v and %v", got, back)
+			}
+		})
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Point
+		want    float64
+		compass string
+	}{
+		{"north", Point{0, 0}, Point{1, 0}, 0, "N"},
+		{"east", Point{0, 0}, Point{0, 1}, 90, "E"},
+		{"south", Point{1, 0}, Point{0, 0}, 180, "S"},
+		{"west", Point{0, 1}, Point{0, 0}, 270, "W"},
+		{"Charlottesville to Richmond", charlottesville, richmond, 121, "SE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Bearing(tt.a, tt.b)
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("Bearing() = %.1f, want %.1f", got, tt.want)
+			}
+			if compass := CompassPoint(got); compass != tt.compass {
+				t.Errorf("CompassPoint(%.1f) = %s, want %s", got, compass, tt.compass)
+			}
+		})
+	}
+}
+
+func TestBoxAround(t *testing.T) {
+	radius := 50 * Mile
+	box := BoxAround(charlottesville, radius)
+
+	// Every point on the circle must be inside the box
+	for b := 0.0; b < 360; b += 5 {
+		p := destination(charlottesville, b, radius)
+		if p.Latitude < box.South || p.Latitude > box.North || p.Longitude < box.West || p.Longitude > box.East {
+			t.Errorf("point %s at bearing %.0f is outside %+v", p, b, box)
+		}
+	}
+
+	if whole := BoxAround(Point{Latitude: 89.9}, radius); whole.West != -180 || whole.East != 180 {
+		t.Errorf("box near the pole = %+v, want all longitudes", whole)
+	}
+	if whole := BoxAround(Point{Longitude: 179.9}, radius); whole.West != -180 || whole.East != 180 {
+		t.Errorf("box at the antimeridian = %+v, want all longitudes", whole)
+	}
+}
@@ -0,0 +1,91 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Place is a spot to check for a nearby quilt shop, like a town or a
+// point on a grid
+type Place struct {
+	Name  string
+	State string
+	Point Point
+}
+
+// Gap is a place with no quilt shop within the threshold
+type Gap struct {
+	Place
+	// Nearest is the closest shop, with the zero Match when the database
+	// has none
+	Nearest Match
+}
+
+// GridPlaces covers box with points about spacing apart, in rows from south
+// to north. Each row is spaced in longitude to keep the points spacing
+// apart on the ground. The points are named by their coordinates.
+func GridPlaces(box Box, spacing Distance, state string) []Place {
+	if spacing <= 0 {
+		return nil
+	}
+
+	var places []Place
+	dLat := degrees(float64(spacing / earthRadius))
+	for lat := box.South + dLat/2; lat <= box.North; lat += dLat {
+		dLon := dLat / math.Max(math.Cos(radians(lat)), 0.01)
+		for lon := box.West + dLon/2; lon <= box.East; lon += dLon {
+			p := Point{Latitude: lat, Longitude: lon}
+			places = append(places, Place{Name: p.String(), State: state, Point: p})
+		}
+	}
+	return places
+}
+
+// Gaps returns the places whose nearest shop is farther than threshold,
+// farthest first. Every place is a gap in a database without shops.
+func (d *DB) Gaps(ctx context.Context, places []Place, threshold Distance) ([]Gap, error) {
+	var gaps []Gap
+	for _, place := range places {
+		nearest, err := d.Nearest(ctx, place.Point, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the shop nearest %s: %w", place.Name, err)
+		}
+		gap := Gap{Place: place}
+		if len(nearest) > 0 {
+			if nearest[0].Distance <= threshold {
+				continue
+			}
+			gap.Nearest = nearest[0]
+		}
+		gaps = append(gaps, gap)
+	}
+
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].Nearest.Distance > gaps[j].Nearest.Distance
+	})
+	return gaps, nil
+}
+
+// States returns the states that have shops, in order
+func (d *DB) States(ctx context.Context) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT DISTINCT state FROM quilt_shops ORDER BY state")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []string
+	for rows.Next() {
+		var state string
+		if err := rows.Scan(&state); err != nil {
+			return nil, fmt.Errorf("failed to scan state: %w", err)
+		}
+		states = append(states, state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read states: %w", err)
+	}
+	return states, nil
+}
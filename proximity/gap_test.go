@@ -0,0 +1,63 @@
+package proximity
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGridPlaces(t *testing.T) {
+	box := Box{South: 38, North: 39, West: -79, East: -78}
+	spacing := 10 * Mile
+	places := GridPlaces(box, spacing, "VA")
+
+	// A degree of latitude is about 69 miles and of longitude here about 54
+	if len(places) < 30 || len(places) > 45 {
+		t.Errorf("GridPlaces() returned %d points, want about 7 rows of 5", len(places))
+	}
+	for _, p := range places {
+		if p.Point.Latitude < box.South || p.Point.Latitude > box.North || p.Point.Longitude < box.West || p.Point.Longitude > box.East {
+			t.Errorf("point %s is outside %+v", p.Point, box)
+		}
+		if p.State != "VA" || p.Name == "" {
+			t.Errorf("place = %+v, want a named VA place", p)
+		}
+	}
+	if d := Haversine(places[0].Point, places[1].Point); d < spacing*0.95 || d > spacing*1.05 {
+		t.Errorf("neighboring points are %.1f mi apart, want about 10", d.Miles())
+	}
+
+	if got := GridPlaces(box, 0, "VA"); got != nil {
+		t.Errorf("GridPlaces() with no spacing = %v, want nil", got)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	db := newTestDB(t)
+	places := []Place{
+		{Name: "Charlottesville", State: "VA", Point: charlottesville},
+		{Name: "Lynchburg", State: "VA", Point: Point{Latitude: 37.4138, Longitude: -79.1422}},
+		{Name: "Bakersfield", State: "CA", Point: Point{Latitude: 35.3733, Longitude: -119.0187}},
+	}
+
+	gaps, err := db.Gaps(context.Background(), places, 50*Mile)
+	if err != nil {
+		t.Fatalf("Gaps() error = %v", err)
+	}
+	if len(gaps) != 2 || gaps[0].Name != "Bakersfield" || gaps[1].Name != "Lynchburg" {
+		t.Fatalf("Gaps() = %+v, want Bakersfield then Lynchburg", gaps)
+	}
+	if gaps[0].Nearest.ID != 5 || gaps[1].Nearest.ID != 1 {
+		t.Errorf("nearest shops = %d and %d, want 5 and 1", gaps[0].Nearest.ID, gaps[1].Nearest.ID)
+	}
+	if miles := gaps[1].Nearest.Distance.Miles(); miles < 50 || miles > 70 {
+		t.Errorf("Lynchburg is %.1f mi from a shop, want about 57", miles)
+	}
+
+	states, err := db.States(context.Background())
+	if err != nil {
+		t.Fatalf("States() error = %v", err)
+	}
+	if len(states) != 2 || states[0] != "CA" || states[1] != "VA" {
+		t.Errorf("States() = %v, want [CA VA]", states)
+	}
+}
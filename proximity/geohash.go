@@ -0,0 +1,178 @@
+package proximity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// geohashAlphabet is the base 32 alphabet of geohashes
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeohashPrecision is the longest geohash Geohash produces, about 2 cm
+// across, well past the precision of a geocoded address
+const MaxGeohashPrecision = 12
+
+// Geohash encodes p as a geohash of precision characters. Each character
+// narrows the cell; 5 is about 5 km across, 7 about 150 m and 9 about 5 m.
+// Shops sharing a prefix are in the same cell at that precision.
+func Geohash(p Point, precision int) string {
+	precision = min(max(precision, 1), MaxGeohashPrecision)
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	var hash strings.Builder
+	bits, ch := 0, 0
+	even := true
+	for hash.Len() < precision {
+		// Bits alternate between longitude and latitude, longitude first
+		value, r := p.Longitude, &lonRange
+		if !even {
+			value, r = p.Latitude, &latRange
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if value >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+
+		if bits++; bits == 5 {
+			hash.WriteByte(geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// GeohashBox returns the cell a geohash covers
+func GeohashBox(hash string) (Box, error) {
+	box := Box{South: -90, North: 90, West: -180, East: 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		ch := strings.IndexRune(geohashAlphabet, c)
+		if ch < 0 {
+			return Box{}, fmt.Errorf("invalid geohash %q", hash)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			on := ch&(1<<bit) != 0
+			if even {
+				mid := (box.West + box.East) / 2
+				if on {
+					box.West = mid
+				} else {
+					box.East = mid
+				}
+			} else {
+				mid := (box.South + box.North) / 2
+				if on {
+					box.South = mid
+				} else {
+					box.North = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return box, nil
+}
+
+// GeohashNeighbors returns the cell itself followed by the up to 8 cells
+// around it at the same precision. Cells past a pole are left out and
+// longitude wraps at the antimeridian.
+func GeohashNeighbors(hash string) ([]string, error) {
+	box, err := GeohashBox(hash)
+	if err != nil {
+		return nil, err
+	}
+	height, width := box.North-box.South, box.East-box.West
+	center := Point{Latitude: (box.South + box.North) / 2, Longitude: (box.West + box.East) / 2}
+
+	cells := []string{strings.ToLower(hash)}
+	seen := map[string]bool{cells[0]: true}
+	for _, dLat := range []float64{1, 0, -1} {
+		for _, dLon := range []float64{-1, 0, 1} {
+			lat := center.Latitude + dLat*height
+			if lat > 90 || lat < -90 {
+				continue
+			}
+			lon := math.Mod(center.Longitude+dLon*width+540, 360) - 180
+			cell := Geohash(Point{Latitude: lat, Longitude: lon}, len(hash))
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells, nil
+}
+
+// Neighborhood returns the shops in the geohash cell around from and the 8
+// cells next to it at precision, nearest first. It only compares geohash
+// prefixes in SQL, so it needs the geohash column merge adds, and fails when
+// precision is finer than the geohashes merge stored. Shops at the far
+// corners of the neighboring cells can be farther than closer shops outside
+// them; use Nearest or WithinRadius when that matters.
+func (d *DB) Neighborhood(ctx context.Context, from Point, precision int) ([]Match, error) {
+	stored, err := d.geohashPrecision(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stored > 0 && precision > stored {
+		return nil, fmt.Errorf("precision %d is finer than the database's %d character geohashes", precision, stored)
+	}
+
+	cells, err := GeohashNeighbors(Geohash(from, precision))
+	if err != nil {
+		return nil, err
+	}
+
+	// A prefix is a range of the geohash index: every hash starting with
+	// the cell sorts from the cell itself up to the cell followed by "~"
+	var conditions []string
+	var args []interface{}
+	for _, cell := range cells {
+		conditions = append(conditions, "(geohash >= ? AND geohash < ?)")
+		args = append(args, cell, cell+"~")
+	}
+
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+d.shopColumns()+`
+		FROM quilt_shops
+		WHERE `+strings.Join(conditions, " OR "), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shops (rebuild the database with merge to add geohashes): %w", err)
+	}
+	defer rows.Close()
+
+	shops, err := scanShops(rows)
+	if err != nil {
+		return nil, err
+	}
+	return rank(from, shops), nil
+}
+
+// geohashPrecision returns the length of the geohashes merge stored: the
+// geohash_precision metadata, or for databases merged before it was
+// recorded, the longest geohash. It is 0 when there are none.
+func (d *DB) geohashPrecision(ctx context.Context) (int, error) {
+	var value string
+	err := d.db.QueryRowContext(ctx, "SELECT value FROM metadata WHERE key = 'geohash_precision'").Scan(&value)
+	if err == nil {
+		if precision, err := strconv.Atoi(value); err == nil {
+			return precision, nil
+		}
+	}
+
+	var longest sql.NullInt64
+	if err := d.db.QueryRowContext(ctx, "SELECT MAX(LENGTH(geohash)) FROM quilt_shops").Scan(&longest); err != nil {
+		return 0, fmt.Errorf("failed to read geohashes (rebuild the database with merge to add them): %w", err)
+	}
+	return int(longest.Int64), nil
+}
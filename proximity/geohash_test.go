@@ -0,0 +1,119 @@
+package proximity
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		p         Point
+		precision int
+		want      string
+	}{
+		// Reference values from the original geohash.org examples
+		{Point{Latitude: 57.64911, Longitude: 10.40744}, 11, "u4pruydqqvj"},
+		{Point{Latitude: 42.6, Longitude: -5.6}, 5, "ezs42"},
+		{Point{Latitude: 0, Longitude: 0}, 1, "s"},
+		{Point{Latitude: -90, Longitude: -180}, 3, "000"},
+	}
+
+	for _, tt := range tests {
+		if got := Geohash(tt.p, tt.precision); got != tt.want {
+			t.Errorf("Geohash(%s, %d) = %s, want %s", tt.p, tt.precision, got, tt.want)
+		}
+	}
+
+	if got := len(Geohash(charlottesville, 99)); got != MaxGeohashPrecision {
+		t.Errorf("Geohash precision capped at %d, want %d", got, MaxGeohashPrecision)
+	}
+}
+
+func TestGeohashBox(t *testing.T) {
+	box, err := GeohashBox("ezs42")
+	if err != nil {
+		t.Fatalf("GeohashBox() error = %v", err)
+	}
+	p := Point{Latitude: 42.6, Longitude: -5.6}
+	if p.Latitude < box.South || p.Latitude > box.North || p.Longitude < box.West || p.Longitude > box.East {
+		t.Errorf("GeohashBox(ezs42) = %+v, doesn't contain %s", box, p)
+	}
+
+	if _, err := GeohashBox("abc"); err == nil {
+		t.Error("GeohashBox(abc) error = nil, want invalid geohash")
+	}
+}
+
+func TestGeohashNeighbors(t *testing.T) {
+	got, err := GeohashNeighbors("dqcjq")
+	if err != nil {
+		t.Fatalf("GeohashNeighbors() error = %v", err)
+	}
+	if got[0] != "dqcjq" {
+		t.Errorf("first cell = %s, want the cell itself", got[0])
+	}
+	// Neighbors from the geohash reference implementation
+	want := []string{"dqcjq", "dqcjw", "dqcjx", "dqcjr", "dqcjp", "dqcjn", "dqcjj", "dqcjm", "dqcjt"}
+	sort.Strings(got)
+	sort.Strings(want)
+	for i := range want {
+		if i >= len(got) || got[i] != want[i] {
+			t.Fatalf("GeohashNeighbors(dqcjq) = %v, want %v", got, want)
+		}
+	}
+
+	// At the pole there is nothing further north
+	if polar, _ := GeohashNeighbors(Geohash(Point{Latitude: 89.99, Longitude: 0}, 3)); len(polar) != 6 {
+		t.Errorf("polar neighbors = %v, want 6 cells", polar)
+	}
+	// Longitude wraps at the antimeridian
+	wrap, _ := GeohashNeighbors(Geohash(Point{Latitude: 0, Longitude: 179.99}, 3))
+	found := false
+	for _, cell := range wrap {
+		if box, _ := GeohashBox(cell); box.West == -180 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("neighbors %v don't wrap past 180", wrap)
+	}
+}
+
+func TestNeighborhood(t *testing.T) {
+	db := newTestDB(t)
+	for _, s := range testShops {
+		if _, err := db.db.Exec("UPDATE quilt_shops SET geohash = ? WHERE id = ?", Geohash(s.Point(), 9), s.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Precision 3 cells are about 150 km across, so this covers Virginia but
+	// not California
+	matches, err := db.Neighborhood(context.Background(), charlottesville, 3)
+	if err != nil {
+		t.Fatalf("Neighborhood() error = %v", err)
+	}
+	if got, want := ids(matches), []int{1, 3, 2, 4}; !slices.Equal(got, want) {
+		t.Fatalf("Neighborhood() = %v, want %v", got, want)
+	}
+
+	// The stored geohashes have 9 characters, so there's nothing to match
+	// finer cells against
+	if _, err := db.Neighborhood(context.Background(), charlottesville, 10); err == nil {
+		t.Error("Neighborhood() at precision 10 error = nil, want an error")
+	}
+
+	// Metadata from merge records the precision
+	if _, err := db.db.Exec(`CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT);
+		INSERT INTO metadata (key, value) VALUES ('geohash_precision', '4')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Neighborhood(context.Background(), charlottesville, 4); err != nil {
+		t.Errorf("Neighborhood() at the stored precision error = %v", err)
+	}
+	if _, err := db.Neighborhood(context.Background(), charlottesville, 5); err == nil {
+		t.Error("Neighborhood() finer than the metadata's precision error = nil, want an error")
+	}
+}
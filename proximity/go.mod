@@ -1,26 +1,19 @@
-module github.com/chicks-net/quilt-shop-proximity
+module github.com/chicks-net/quilt-shop-proximity/proximity
 
 go 1.21
 
-require (
-	github.com/PuerkitoBio/goquery v1.8.1
-	github.com/chicks-net/quilt-shop-proximity/geocode v0.0.0
-	modernc.org/sqlite v1.28.0
-)
-
-replace github.com/chicks-net/quilt-shop-proximity/geocode => ../geocode
+require modernc.org/sqlite v1.28.0
 
 require (
-	github.com/andybalholm/cascadia v1.3.1 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
 	github.com/mattn/go-isatty v0.0.16 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
-	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
-	golang.org/x/net v0.7.0 // indirect
+	golang.org/x/mod v0.3.0 // indirect
 	golang.org/x/sys v0.9.0 // indirect
-	golang.org/x/tools v0.1.12 // indirect
+	golang.org/x/tools v0.0.0-20201124115921-2c860bdd6e78 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	lukechampine.com/uint128 v1.2.0 // indirect
 	modernc.org/cc/v3 v3.40.0 // indirect
 	modernc.org/ccgo/v3 v3.16.13 // indirect
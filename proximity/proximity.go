@@ -0,0 +1,194 @@
+// Package proximity answers "which quilt shops are near here" from the
+// merged quilt_shops database.
+package proximity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	_ "modernc.org/sqlite"
+)
+
+// Shop is one row of the merged quilt_shops table
+type Shop struct {
+	ID        int
+	Name      string
+	Address   string
+	City      string
+	State     string
+	Phone     string
+	Email     string
+	Website   string
+	Latitude  float64
+	Longitude float64
+	// Approximate marks a shop placed at the middle of its town or ZIP code
+	// because its address couldn't be found, to be shown as approximate
+	Approximate bool
+}
+
+// Point returns the shop's location
+func (s Shop) Point() Point {
+	return Point{Latitude: s.Latitude, Longitude: s.Longitude}
+}
+
+// Match is a shop with its distance and bearing from the search point
+type Match struct {
+	Shop
+	Distance Distance
+	// Bearing is the compass bearing to the shop in degrees
+	Bearing float64
+}
+
+// initialRadius is the first box Nearest searches, doubled until it holds
+// enough shops
+const initialRadius = 25 * Mile
+
+// DB queries a merged quilt shops database
+type DB struct {
+	db *sql.DB
+	// rtree reports that the database has the quilt_shops_rtree spatial
+	// index, which older merged databases lack
+	rtree bool
+	// matchType reports that quilt_shops has the geocode_match_type
+	// column, which older merged databases also lack
+	matchType bool
+}
+
+// Open opens the merged SQLite database at path
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return New(db), nil
+}
+
+// New returns a DB using an already open database
+func New(db *sql.DB) *DB {
+	var tables int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'quilt_shops_rtree'").Scan(&tables)
+	var columns int
+	db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('quilt_shops') WHERE name = 'geocode_match_type'").Scan(&columns)
+	return &DB{db: db, rtree: tables > 0, matchType: columns > 0}
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// shopColumns returns the quilt_shops columns scanned into a Shop. A
+// locality or region match is only the middle of the town or ZIP code.
+func (d *DB) shopColumns() string {
+	approximate := "0"
+	if d.matchType {
+		approximate = "COALESCE(geocode_match_type IN ('locality', 'region'), 0)"
+	}
+	return `id, name, COALESCE(address, ''), city, state, COALESCE(phone, ''),
+	COALESCE(email, ''), COALESCE(website, ''), latitude, longitude, ` + approximate
+}
+
+// Nearest returns the n shops closest to from, nearest first. It searches a
+// box around the point on the latitude/longitude index, widening the box
+// until it is sure no shop outside could be closer, and ranks the shops
+// inside by great-circle distance.
+func (d *DB) Nearest(ctx context.Context, from Point, n int) ([]Match, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	for radius := initialRadius; ; radius *= 2 {
+		box := BoxAround(from, radius)
+		shops, err := d.shopsInBox(ctx, box, Filter{})
+		if err != nil {
+			return nil, err
+		}
+		matches := rank(from, shops)
+
+		// A shop outside the box is farther than radius, so the box is big
+		// enough once the nth match is within it. A box covering the whole
+		// globe holds everything.
+		whole := box.South <= -90 && box.North >= 90
+		if len(matches) >= n && matches[n-1].Distance <= radius {
+			return matches[:n], nil
+		}
+		if whole {
+			if len(matches) > n {
+				matches = matches[:n]
+			}
+			return matches, nil
+		}
+	}
+}
+
+// shopsInBox returns the shops inside box that pass filter. It uses the
+// R*Tree index when the database has one and the latitude/longitude
+// B-tree index otherwise.
+func (d *DB) shopsInBox(ctx context.Context, box Box, filter Filter) ([]Shop, error) {
+	where, args := filter.where()
+	args = append([]interface{}{box.South, box.North, box.West, box.East}, args...)
+
+	// The R*Tree stores 32-bit floats rounded outward, so it can return
+	// shops a hair outside the box; they are ranked by exact distance later
+	inBox := "latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?"
+	if d.rtree {
+		inBox = `id IN (
+			SELECT id FROM quilt_shops_rtree
+			WHERE max_lat >= ? AND min_lat <= ? AND max_lon >= ? AND min_lon <= ?
+		)`
+	}
+
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+d.shopColumns()+`
+		FROM quilt_shops
+		WHERE `+inBox+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shops: %w", err)
+	}
+	defer rows.Close()
+	return scanShops(rows)
+}
+
+// scanShops reads rows selected with DB.shopColumns
+func scanShops(rows *sql.Rows) ([]Shop, error) {
+	var shops []Shop
+	for rows.Next() {
+		var s Shop
+		err := rows.Scan(&s.ID, &s.Name, &s.Address, &s.City, &s.State, &s.Phone,
+			&s.Email, &s.Website, &s.Latitude, &s.Longitude, &s.Approximate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shop: %w", err)
+		}
+		shops = append(shops, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shops: %w", err)
+	}
+	return shops, nil
+}
+
+// rank measures each shop from from and sorts them nearest first, breaking
+// ties by id so results are stable
+func rank(from Point, shops []Shop) []Match {
+	matches := make([]Match, len(shops))
+	for i, shop := range shops {
+		matches[i] = Match{
+			Shop:     shop,
+			Distance: Haversine(from, shop.Point()),
+			Bearing:  Bearing(from, shop.Point()),
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
@@ -0,0 +1,193 @@
+package proximity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testShops are placed around Virginia and California
+var testShops = []Shop{
+	{ID: 1, Name: "Downtown Quilts", City: "Charlottesville", State: "VA", Latitude: 38.0301, Longitude: -78.4795},
+	{ID: 2, Name: "River City Fabrics", City: "Richmond", State: "VA", Latitude: 37.5407, Longitude: -77.4360},
+	{ID: 3, Name: "Valley Stitches", City: "Harrisonburg", State: "VA", Latitude: 38.4496, Longitude: -78.8689},
+	{ID: 4, Name: "Coastal Cottons", City: "Norfolk", State: "VA", Latitude: 36.8508, Longitude: -76.2859},
+	{ID: 5, Name: "Sunset Sewing", City: "Los Angeles", State: "CA", Latitude: 34.0522, Longitude: -118.2437},
+	{ID: 6, Name: "Bay Batiks", City: "San Francisco", State: "CA", Latitude: 37.7749, Longitude: -122.4194},
+}
+
+// newTestDB returns an in-memory database holding testShops in the merged
+// schema
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	return newTestDBIndexed(t, true)
+}
+
+// newTestDBIndexed is newTestDB with or without the R*Tree index of older
+// merged databases
+func newTestDBIndexed(t *testing.T, rtree bool) *DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		CREATE TABLE quilt_shops (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			address TEXT,
+			city TEXT NOT NULL,
+			state TEXT NOT NULL,
+			phone TEXT,
+			email TEXT,
+			website TEXT,
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			geocode_attempted_at DATETIME,
+			geohash TEXT
+		);
+		CREATE INDEX idx_coordinates ON quilt_shops(latitude, longitude);
+		CREATE INDEX idx_geohash ON quilt_shops(geohash);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtree {
+		if _, err := db.Exec("CREATE VIRTUAL TABLE quilt_shops_rtree USING rtree(id, min_lat, max_lat, min_lon, max_lon)"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range testShops {
+		_, err := db.Exec("INSERT INTO quilt_shops (id, name, city, state, latitude, longitude) VALUES (?, ?, ?, ?, ?, ?)",
+			s.ID, s.Name, s.City, s.State, s.Latitude, s.Longitude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rtree {
+			_, err := db.Exec("INSERT INTO quilt_shops_rtree VALUES (?, ?, ?, ?, ?)",
+				s.ID, s.Latitude, s.Latitude, s.Longitude, s.Longitude)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	d := New(db)
+	if d.rtree != rtree {
+		t.Fatalf("rtree = %v, want %v", d.rtree, rtree)
+	}
+	return d
+}
+
+// ids lists the shop ids of shops, matches or route matches in order
+func ids[T Shop | Match | RouteMatch](items []T) []int {
+	var out []int
+	for _, item := range items {
+		switch v := any(item).(type) {
+		case Shop:
+			out = append(out, v.ID)
+		case Match:
+			out = append(out, v.ID)
+		case RouteMatch:
+			out = append(out, v.ID)
+		}
+	}
+	return out
+}
+
+func TestNearest(t *testing.T) {
+	tests := []struct {
+		name string
+		from Point
+		n    int
+		want []int
+	}{
+		{"closest first", charlottesville, 3, []int{1, 3, 2}},
+		{"needs a wider box", charlottesville, 5, []int{1, 3, 2, 4, 5}},
+		{"more than there are", charlottesville, 10, []int{1, 3, 2, 4, 5, 6}},
+		{"far from everything", Point{Latitude: -33.87, Longitude: 151.21}, 1, []int{6}},
+		{"none wanted", charlottesville, 0, nil},
+	}
+
+	for _, rtree := range []bool{true, false} {
+		db := newTestDBIndexed(t, rtree)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/rtree=%v", tt.name, rtree), func(t *testing.T) {
+				matches, err := db.Nearest(context.Background(), tt.from, tt.n)
+				if err != nil {
+					t.Fatalf("Nearest() error = %v", err)
+				}
+				got := ids(matches)
+				if len(got) != len(tt.want) {
+					t.Fatalf("Nearest() = %v, want %v", got, tt.want)
+				}
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Fatalf("Nearest() = %v, want %v", got, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestNearestDetails(t *testing.T) {
+	db := newTestDB(t)
+
+	matches, err := db.Nearest(context.Background(), charlottesville, 3)
+	if err != nil {
+		t.Fatalf("Nearest() error = %v", err)
+	}
+	richmond := matches[2]
+	if richmond.Name != "River City Fabrics" || richmond.State != "VA" {
+		t.Errorf("third match = %+v, want River City Fabrics", richmond.Shop)
+	}
+	if miles := richmond.Distance.Miles(); miles < 65 || miles > 67 {
+		t.Errorf("Distance = %.1f mi, want about 66", miles)
+	}
+	if CompassPoint(richmond.Bearing) != "SE" {
+		t.Errorf("Bearing = %.0f, want southeast", richmond.Bearing)
+	}
+}
+
+func TestApproximate(t *testing.T) {
+	// Databases merged before geocode_match_type was kept have no
+	// approximate shops
+	old := newTestDB(t)
+	shops, err := old.AllShops(context.Background())
+	if err != nil {
+		t.Fatalf("AllShops() error = %v", err)
+	}
+	for _, s := range shops {
+		if s.Approximate {
+			t.Errorf("%s is approximate in a database without match types", s.Name)
+		}
+	}
+
+	if _, err := old.db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_match_type TEXT"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.db.Exec("UPDATE quilt_shops SET geocode_match_type = CASE id WHEN 1 THEN 'locality' WHEN 2 THEN 'region' WHEN 3 THEN 'rooftop' END")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shops, err = New(old.db).AllShops(context.Background())
+	if err != nil {
+		t.Fatalf("AllShops() error = %v", err)
+	}
+	var approximate []int
+	for _, s := range shops {
+		if s.Approximate {
+			approximate = append(approximate, s.ID)
+		}
+	}
+	if !slices.Equal(approximate, []int{1, 2}) {
+		t.Errorf("approximate shops = %v, want the locality and region matches [1 2]", approximate)
+	}
+}
@@ -0,0 +1,129 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Filter narrows a search to shops in one state or city. Empty fields
+// match every shop, and matching ignores case.
+type Filter struct {
+	State string
+	City  string
+}
+
+// where returns the SQL conditions for the filter, each starting with AND
+func (f Filter) where() (string, []interface{}) {
+	var sql string
+	var args []interface{}
+	if state := strings.TrimSpace(f.State); state != "" {
+		sql += " AND state = ? COLLATE NOCASE"
+		args = append(args, state)
+	}
+	if city := strings.TrimSpace(f.City); city != "" {
+		sql += " AND city = ? COLLATE NOCASE"
+		args = append(args, city)
+	}
+	return sql, args
+}
+
+// RadiusQuery asks for the shops within Radius of Center, nearest first,
+// one page at a time
+type RadiusQuery struct {
+	Center Point
+	Radius Distance
+	Filter Filter
+
+	// Offset skips that many of the nearest shops
+	Offset int
+	// Limit is the page size; 0 returns every shop from Offset on
+	Limit int
+}
+
+// Page is one page of radius search results
+type Page struct {
+	Matches []Match
+	// Total is how many shops are within the radius across all pages
+	Total int
+}
+
+// WithinRadius returns the shops within q.Radius of q.Center that pass
+// q.Filter, sorted by distance and paged by q.Offset and q.Limit
+func (d *DB) WithinRadius(ctx context.Context, q RadiusQuery) (Page, error) {
+	if q.Radius < 0 {
+		return Page{}, fmt.Errorf("radius %v is negative", q.Radius)
+	}
+
+	shops, err := d.shopsInBox(ctx, BoxAround(q.Center, q.Radius), q.Filter)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// The box corners are farther than the radius
+	var matches []Match
+	for _, m := range rank(q.Center, shops) {
+		if m.Distance <= q.Radius {
+			matches = append(matches, m)
+		}
+	}
+
+	page := Page{Total: len(matches)}
+	if q.Offset >= len(matches) {
+		return page, nil
+	}
+	matches = matches[max(q.Offset, 0):]
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	page.Matches = matches
+	return page, nil
+}
+
+// units maps the accepted unit names to their size
+var units = map[string]Distance{
+	"m": Meter, "meter": Meter, "meters": Meter,
+	"km": Kilometer, "kilometer": Kilometer, "kilometers": Kilometer,
+	"mi": Mile, "mile": Mile, "miles": Mile,
+}
+
+// ParseUnit returns the size of a unit named like "mi", "miles" or "km"
+func ParseUnit(name string) (Distance, error) {
+	unit, ok := units[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unknown distance unit %q (use mi or km)", name)
+	}
+	return unit, nil
+}
+
+// ParseDistance reads a distance like "50mi", "80 km" or "12.5 miles". A
+// bare number is in defaultUnit.
+func ParseDistance(s string, defaultUnit Distance) (Distance, error) {
+	s = strings.TrimSpace(s)
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unitName := s, ""
+	if split >= 0 {
+		number, unitName = s[:split], s[split:]
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid distance %q", s)
+	}
+
+	unit := defaultUnit
+	if strings.TrimSpace(unitName) != "" {
+		if unit, err = ParseUnit(unitName); err != nil {
+			return 0, err
+		}
+	}
+	return Distance(value) * unit, nil
+}
+
+// In returns the distance measured in unit, such as d.In(Kilometer)
+func (d Distance) In(unit Distance) float64 {
+	return float64(d / unit)
+}
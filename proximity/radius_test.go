@@ -0,0 +1,84 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestWithinRadius(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     RadiusQuery
+		want      []int
+		wantTotal int
+	}{
+		{"50 miles", RadiusQuery{Center: charlottesville, Radius: 50 * Mile}, []int{1, 3}, 2},
+		{"in kilometers", RadiusQuery{Center: charlottesville, Radius: 110 * Kilometer}, []int{1, 3, 2}, 3},
+		{"box corner is outside the radius", RadiusQuery{Center: charlottesville, Radius: 66 * Mile}, []int{1, 3}, 2},
+		{"state filter", RadiusQuery{Center: charlottesville, Radius: 5000 * Mile, Filter: Filter{State: "ca"}}, []int{5, 6}, 2},
+		{"city filter", RadiusQuery{Center: charlottesville, Radius: 500 * Mile, Filter: Filter{City: "Norfolk"}}, []int{4}, 1},
+		{"first page", RadiusQuery{Center: charlottesville, Radius: 500 * Mile, Limit: 2}, []int{1, 3}, 4},
+		{"second page", RadiusQuery{Center: charlottesville, Radius: 500 * Mile, Offset: 2, Limit: 2}, []int{2, 4}, 4},
+		{"past the end", RadiusQuery{Center: charlottesville, Radius: 500 * Mile, Offset: 10, Limit: 2}, nil, 4},
+		{"nothing nearby", RadiusQuery{Center: Point{Latitude: 45, Longitude: -100}, Radius: 10 * Mile}, nil, 0},
+	}
+
+	for _, rtree := range []bool{true, false} {
+		db := newTestDBIndexed(t, rtree)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/rtree=%v", tt.name, rtree), func(t *testing.T) {
+				page, err := db.WithinRadius(context.Background(), tt.query)
+				if err != nil {
+					t.Fatalf("WithinRadius() error = %v", err)
+				}
+				if page.Total != tt.wantTotal {
+					t.Errorf("Total = %d, want %d", page.Total, tt.wantTotal)
+				}
+				got := ids(page.Matches)
+				if len(got) != len(tt.want) {
+					t.Fatalf("WithinRadius() = %v, want %v", got, tt.want)
+				}
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Fatalf("WithinRadius() = %v, want %v", got, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Distance
+		wantErr bool
+	}{
+		{"50", 50 * Mile, false},
+		{"50mi", 50 * Mile, false},
+		{"80 km", 80 * Kilometer, false},
+		{"12.5 Miles", 12.5 * Mile, false},
+		{"500m", 500 * Meter, false},
+		{"", 0, true},
+		{"far", 0, true},
+		{"10 leagues", 0, true},
+		{"-5mi", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDistance(tt.in, Mile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDistance(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDistance(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if km := (5 * Mile).In(Kilometer); km < 8.04 || km > 8.05 {
+		t.Errorf("5 miles = %.3f km, want 8.047", km)
+	}
+}
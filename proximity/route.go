@@ -0,0 +1,153 @@
+package proximity
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// RouteMatch is a shop near a route
+type RouteMatch struct {
+	// Distance is how far the shop is off the route, and Bearing points
+	// from the closest point of the route to the shop
+	Match
+	// Along is how far down the route the closest point is
+	Along Distance
+}
+
+// routeStep is the most a route goes between points when building search
+// boxes; longer segments get points added along their great circle, which
+// can bow well away from a box around the ends
+const routeStep = 10 * Mile
+
+// routeChunk is how much route shares one search box
+const routeChunk = 50 * Mile
+
+// RouteLength returns the length of a route through points
+func RouteLength(route []Point) Distance {
+	var total Distance
+	for i := 1; i < len(route); i++ {
+		total += Haversine(route[i-1], route[i])
+	}
+	return total
+}
+
+// AlongRoute returns the shops within width of a route that pass filter, in
+// the order a driver following the route reaches them. The route is a path
+// of great-circle segments between points, like a GPX track or a list of
+// waypoints.
+func (d *DB) AlongRoute(ctx context.Context, route []Point, width Distance, filter Filter) ([]RouteMatch, error) {
+	if len(route) == 0 || width < 0 {
+		return nil, nil
+	}
+
+	// Gather the candidates from one box per stretch of the route
+	candidates := map[int]Shop{}
+	for _, box := range routeBoxes(route, width) {
+		shops, err := d.shopsInBox(ctx, box, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range shops {
+			candidates[s.ID] = s
+		}
+	}
+
+	var matches []RouteMatch
+	for _, shop := range candidates {
+		best := RouteMatch{Match: Match{Shop: shop, Distance: Haversine(route[0], shop.Point())}}
+		closest := route[0]
+		var start Distance
+		for i := 1; i < len(route); i++ {
+			off, along, at := closestOnSegment(shop.Point(), route[i-1], route[i])
+			if off < best.Distance {
+				best.Distance, best.Along, closest = off, start+along, at
+			}
+			start += Haversine(route[i-1], route[i])
+		}
+		if best.Distance > width {
+			continue
+		}
+		best.Bearing = Bearing(closest, shop.Point())
+		matches = append(matches, best)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Along != matches[j].Along {
+			return matches[i].Along < matches[j].Along
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches, nil
+}
+
+// routeBoxes covers every point within width of the route with a box per
+// routeChunk of it
+func routeBoxes(route []Point, width Distance) []Box {
+	var boxes []Box
+	var box Box
+	var length Distance
+	empty := true
+	add := func(p Point) {
+		around := BoxAround(p, width)
+		if empty {
+			box, empty = around, false
+			return
+		}
+		box.South = math.Min(box.South, around.South)
+		box.North = math.Max(box.North, around.North)
+		box.West = math.Min(box.West, around.West)
+		box.East = math.Max(box.East, around.East)
+	}
+
+	add(route[0])
+	for i := 1; i < len(route); i++ {
+		a, b := route[i-1], route[i]
+		segment := Haversine(a, b)
+		steps := int(math.Ceil(float64(segment / routeStep)))
+		for s := 1; s <= steps; s++ {
+			p := b
+			if s < steps {
+				p = destination(a, Bearing(a, b), segment*Distance(s)/Distance(steps))
+			}
+			add(p)
+			length += segment / Distance(steps)
+			if length >= routeChunk {
+				boxes = append(boxes, box)
+				length, empty = 0, true
+				add(p)
+			}
+		}
+	}
+	if length > 0 || len(boxes) == 0 {
+		boxes = append(boxes, box)
+	}
+	return boxes
+}
+
+// closestOnSegment returns how far p is from the great-circle segment from
+// a to b, how far from a along the segment the closest point is, and that
+// point
+func closestOnSegment(p, a, b Point) (off, along Distance, closest Point) {
+	length := Haversine(a, b)
+	toP := Haversine(a, p)
+	if length == 0 || toP == 0 {
+		return toP, 0, a
+	}
+
+	// Cross-track and along-track distances on the sphere
+	d13 := float64(toP / earthRadius)
+	theta := radians(Bearing(a, p) - Bearing(a, b))
+	crossTrack := math.Asin(math.Sin(d13) * math.Sin(theta))
+	alongTrack := math.Acos(math.Max(-1, math.Min(1, math.Cos(d13)/math.Cos(crossTrack))))
+
+	switch {
+	case math.Cos(theta) < 0:
+		// p is behind a
+		return toP, 0, a
+	case Distance(alongTrack)*earthRadius >= length:
+		return Haversine(b, p), length, b
+	}
+	along = Distance(alongTrack) * earthRadius
+	return Distance(math.Abs(crossTrack)) * earthRadius, along, destination(a, Bearing(a, b), along)
+}
@@ -0,0 +1,150 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// i64 runs Richmond to Charlottesville to Harrisonburg
+var i64 = []Point{
+	{Latitude: 37.5407, Longitude: -77.4360},
+	{Latitude: 38.0293, Longitude: -78.4767},
+	{Latitude: 38.4496, Longitude: -78.8689},
+}
+
+func TestAlongRoute(t *testing.T) {
+	reversed := []Point{i64[2], i64[1], i64[0]}
+	tests := []struct {
+		name   string
+		route  []Point
+		width  Distance
+		filter Filter
+		want   []int
+	}{
+		{"in driving order", i64, 5 * Mile, Filter{}, []int{2, 1, 3}},
+		{"driving the other way", reversed, 5 * Mile, Filter{}, []int{3, 1, 2}},
+		{"narrow corridor", i64, 0.05 * Mile, Filter{}, []int{2, 3}},
+		{"state filter", i64, 5 * Mile, Filter{State: "CA"}, nil},
+		{"one long segment", []Point{{Latitude: 34.05, Longitude: -118.25}, {Latitude: 37.78, Longitude: -122.42}}, 10 * Mile, Filter{}, []int{5, 6}},
+		{"single point", []Point{charlottesville}, 5 * Mile, Filter{}, []int{1}},
+	}
+
+	for _, rtree := range []bool{true, false} {
+		db := newTestDBIndexed(t, rtree)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/rtree=%v", tt.name, rtree), func(t *testing.T) {
+				matches, err := db.AlongRoute(context.Background(), tt.route, tt.width, tt.filter)
+				if err != nil {
+					t.Fatalf("AlongRoute() error = %v", err)
+				}
+				if got := ids(matches); !slices.Equal(got, tt.want) {
+					t.Errorf("AlongRoute() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestAlongRouteDetails(t *testing.T) {
+	matches, err := newTestDB(t).AlongRoute(context.Background(), i64, 5*Mile, Filter{})
+	if err != nil {
+		t.Fatalf("AlongRoute() error = %v", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("AlongRoute() returned %d shops, want 3", len(matches))
+	}
+
+	charlottesvilleShop := matches[1]
+	if off := charlottesvilleShop.Distance.Miles(); off > 0.2 {
+		t.Errorf("Charlottesville is %.2f mi off the route, want under 0.2", off)
+	}
+	if along, want := charlottesvilleShop.Along.Miles(), Haversine(i64[0], i64[1]).Miles(); math.Abs(along-want) > 0.5 {
+		t.Errorf("Charlottesville is %.1f mi along the route, want about %.1f", along, want)
+	}
+	if end, want := matches[2].Along, RouteLength(i64); math.Abs(float64(end-want)) > float64(Mile) {
+		t.Errorf("Harrisonburg is %.1f mi along the route, want about %.1f", end.Miles(), want.Miles())
+	}
+}
+
+func TestClosestOnSegment(t *testing.T) {
+	a, b := Point{}, Point{Longitude: 2}
+	degree := Haversine(Point{}, Point{Latitude: 1})
+
+	tests := []struct {
+		name      string
+		p         Point
+		wantOff   Distance
+		wantAlong Distance
+	}{
+		{"beside the middle", Point{Latitude: 1, Longitude: 1}, degree, degree},
+		{"on the segment", Point{Longitude: 0.5}, 0, degree / 2},
+		{"behind the start", Point{Longitude: -1}, degree, 0},
+		{"past the end", Point{Longitude: 3}, degree, 2 * degree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			off, along, _ := closestOnSegment(tt.p, a, b)
+			// Off a great circle the distance shrinks slightly from a degree
+			if math.Abs(float64(off-tt.wantOff)) > 100 || math.Abs(float64(along-tt.wantAlong)) > 100 {
+				t.Errorf("closestOnSegment() = %.0f m off, %.0f m along, want %.0f, %.0f",
+					off, along, tt.wantOff, tt.wantAlong)
+			}
+		})
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	want := []Point{{Latitude: 38.03, Longitude: -78.48}, {Latitude: 38.45, Longitude: -78.87}}
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{"GPX track", `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><trkseg>
+    <trkpt lat="38.03" lon="-78.48"><ele>150</ele></trkpt>
+    <trkpt lat="38.45" lon="-78.87"></trkpt>
+  </trkseg></trk>
+</gpx>`, false},
+		{"GPX route", `<gpx><rte><rtept lat="38.03" lon="-78.48"/><rtept lat="38.45" lon="-78.87"/></rte></gpx>`, false},
+		{"GPX waypoints", `<gpx><wpt lat="38.03" lon="-78.48"/><wpt lat="38.45" lon="-78.87"/></gpx>`, false},
+		{"GeoJSON LineString", `{"type": "LineString", "coordinates": [[-78.48, 38.03], [-78.87, 38.45]]}`, false},
+		{"GeoJSON Feature", `{"type": "Feature", "properties": {}, "geometry": {"type": "LineString", "coordinates": [[-78.48, 38.03, 150], [-78.87, 38.45, 400]]}}`, false},
+		{"GeoJSON FeatureCollection", `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [-77.4, 37.5]}},
+			{"type": "Feature", "geometry": {"type": "MultiLineString", "coordinates": [[[-78.48, 38.03]], [[-78.87, 38.45]]]}}
+		]}`, false},
+		{"text lines", "# I-81\n38.03,-78.48\n\n38.45, -78.87\n", false},
+		{"text semicolons", "38.03,-78.48; 38.45,-78.87", false},
+		{"empty", "  \n", true},
+		{"GeoJSON without a line", `{"type": "Point", "coordinates": [-78.48, 38.03]}`, true},
+		{"GPX without points", `<gpx></gpx>`, true},
+		{"bad text", "38.03 -78.48", true},
+		{"out of range", `{"type": "LineString", "coordinates": [[38.03, -178.48]]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoute(strings.NewReader(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(want) {
+				t.Fatalf("ParseRoute() = %v, want %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("ParseRoute()[%d] = %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package proximity
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// gpxFile is the part of a GPX file that holds points
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+	Routes []struct {
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Waypoints []gpxPoint `xml:"wpt"`
+}
+
+// gpxPoint is a GPX trkpt, rtept or wpt
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+}
+
+// geoJSONObject is any GeoJSON object: a geometry, a Feature or a
+// FeatureCollection
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+	Features    []geoJSONObject `json:"features"`
+	Geometries  []geoJSONObject `json:"geometries"`
+}
+
+// ParseRoute reads a route as a GPX file, GeoJSON with LineString or
+// MultiLineString geometry, or plain text with one latitude,longitude pair
+// per line. GPX tracks are used before routes, and routes before
+// waypoints. In text, pairs may also be separated by semicolons, and blank
+// lines and lines starting with # are skipped.
+func ParseRoute(r io.Reader) ([]Point, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route: %w", err)
+	}
+
+	var route []Point
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		route, err = parseGPX(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		route, err = parseGeoJSONRoute(trimmed)
+	default:
+		route, err = parseRouteText(string(trimmed))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(route) == 0 {
+		return nil, fmt.Errorf("route has no points")
+	}
+	for _, p := range route {
+		if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+			return nil, fmt.Errorf("route point %s is out of range", p)
+		}
+	}
+	return route, nil
+}
+
+// parseGPX reads the points of a GPX file
+func parseGPX(data []byte) ([]Point, error) {
+	var gpx gpxFile
+	if err := xml.Unmarshal(data, &gpx); err != nil {
+		return nil, fmt.Errorf("failed to parse GPX: %w", err)
+	}
+
+	var points []gpxPoint
+	for _, trk := range gpx.Tracks {
+		for _, seg := range trk.Segments {
+			points = append(points, seg.Points...)
+		}
+	}
+	if len(points) == 0 {
+		for _, rte := range gpx.Routes {
+			points = append(points, rte.Points...)
+		}
+	}
+	if len(points) == 0 {
+		points = gpx.Waypoints
+	}
+
+	route := make([]Point, len(points))
+	for i, p := range points {
+		route[i] = Point{Latitude: p.Latitude, Longitude: p.Longitude}
+	}
+	return route, nil
+}
+
+// parseGeoJSONRoute joins the lines of a GeoJSON object into one route
+func parseGeoJSONRoute(data []byte) ([]Point, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+	route, err := obj.lines()
+	if err != nil {
+		return nil, err
+	}
+	if len(route) == 0 {
+		return nil, fmt.Errorf("GeoJSON has no LineString")
+	}
+	return route, nil
+}
+
+// lines returns the points of every LineString and MultiLineString in the
+// object, in order. Other geometries are skipped.
+func (g geoJSONObject) lines() ([]Point, error) {
+	var route []Point
+	switch g.Type {
+	case "LineString":
+		var coords [][]float64
+		if err := json.Unmarshal(g.Coordinates, &coords); err != nil {
+			return nil, fmt.Errorf("failed to parse LineString: %w", err)
+		}
+		return geoJSONPoints(coords)
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &lines); err != nil {
+			return nil, fmt.Errorf("failed to parse MultiLineString: %w", err)
+		}
+		for _, coords := range lines {
+			points, err := geoJSONPoints(coords)
+			if err != nil {
+				return nil, err
+			}
+			route = append(route, points...)
+		}
+	case "Feature":
+		if g.Geometry != nil {
+			return g.Geometry.lines()
+		}
+	case "FeatureCollection", "GeometryCollection":
+		for _, member := range append(g.Features, g.Geometries...) {
+			points, err := member.lines()
+			if err != nil {
+				return nil, err
+			}
+			route = append(route, points...)
+		}
+	}
+	return route, nil
+}
+
+// geoJSONPoints converts GeoJSON positions, which put longitude first
+func geoJSONPoints(coords [][]float64) ([]Point, error) {
+	points := make([]Point, len(coords))
+	for i, c := range coords {
+		if len(c) < 2 {
+			return nil, fmt.Errorf("GeoJSON position %v needs a longitude and latitude", c)
+		}
+		points[i] = Point{Latitude: c[1], Longitude: c[0]}
+	}
+	return points, nil
+}
+
+// parseRouteText reads latitude,longitude pairs
+func parseRouteText(text string) ([]Point, error) {
+	var route []Point
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, pair := range strings.Split(line, ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			p, err := ParsePoint(pair)
+			if err != nil {
+				return nil, err
+			}
+			route = append(route, p)
+		}
+	}
+	return route, nil
+}
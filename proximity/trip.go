@@ -0,0 +1,202 @@
+package proximity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TripOptions controls how PlanTrip orders the stops
+type TripOptions struct {
+	// RoundTrip returns to the start after the last shop
+	RoundTrip bool
+	// RoadFactor scales great-circle distances to approximate driving
+	// distance, such as 1.3; 0 means 1
+	RoadFactor float64
+}
+
+// Leg is one drive of a trip
+type Leg struct {
+	// From is empty for a leg leaving the start
+	From *Shop
+	// To is nil for the leg back to the start on a round trip
+	To       *Shop
+	Distance Distance
+	Bearing  float64
+}
+
+// Itinerary is an ordered trip through a set of shops
+type Itinerary struct {
+	Start Point
+	Stops []Shop
+	Legs  []Leg
+	Total Distance
+}
+
+// PlanTrip orders shops into a short trip from start. It builds a route by
+// always driving to the nearest unvisited shop, then improves it with 2-opt,
+// reversing any stretch of the route that makes it shorter, until no
+// reversal helps. The result is usually within a few percent of the best
+// possible order.
+func PlanTrip(start Point, shops []Shop, opts TripOptions) Itinerary {
+	// With nothing to visit there's no trip, not even back to the start
+	if len(shops) == 0 {
+		return Itinerary{Start: start}
+	}
+
+	factor := opts.RoadFactor
+	if factor <= 0 {
+		factor = 1
+	}
+
+	// points[0] is the start and points[i+1] is shops[i]
+	points := make([]Point, len(shops)+1)
+	points[0] = start
+	for i, s := range shops {
+		points[i+1] = s.Point()
+	}
+	dist := distanceMatrix(points)
+
+	route := nearestNeighborRoute(dist)
+	if opts.RoundTrip {
+		route = append(route, 0)
+	}
+	twoOpt(route, dist, opts.RoundTrip)
+
+	it := Itinerary{Start: start}
+	for k := 1; k < len(route); k++ {
+		leg := Leg{
+			Distance: dist[route[k-1]][route[k]] * Distance(factor),
+			Bearing:  Bearing(points[route[k-1]], points[route[k]]),
+		}
+		if route[k-1] > 0 {
+			leg.From = &shops[route[k-1]-1]
+		}
+		if route[k] > 0 {
+			leg.To = &shops[route[k]-1]
+			it.Stops = append(it.Stops, shops[route[k]-1])
+		}
+		it.Legs = append(it.Legs, leg)
+		it.Total += leg.Distance
+	}
+	return it
+}
+
+// distanceMatrix returns the distance between every pair of points
+func distanceMatrix(points []Point) [][]Distance {
+	dist := make([][]Distance, len(points))
+	for i := range points {
+		dist[i] = make([]Distance, len(points))
+		for j := range points {
+			dist[i][j] = Haversine(points[i], points[j])
+		}
+	}
+	return dist
+}
+
+// nearestNeighborRoute starts at point 0 and repeatedly visits the closest
+// point not yet visited
+func nearestNeighborRoute(dist [][]Distance) []int {
+	visited := make([]bool, len(dist))
+	route := []int{0}
+	visited[0] = true
+	for len(route) < len(dist) {
+		here := route[len(route)-1]
+		next := -1
+		for j := range dist {
+			if !visited[j] && (next < 0 || dist[here][j] < dist[here][next]) {
+				next = j
+			}
+		}
+		visited[next] = true
+		route = append(route, next)
+	}
+	return route
+}
+
+// twoOpt shortens route in place by reversing stretches of it. The first
+// point stays put, and so does the last on a round trip.
+func twoOpt(route []int, dist [][]Distance, roundTrip bool) {
+	last := len(route) - 1
+	if roundTrip {
+		last--
+	}
+
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < last; i++ {
+			for j := i + 1; j <= last; j++ {
+				// Reversing route[i..j] swaps the edges on either side
+				delta := dist[route[i-1]][route[j]] - dist[route[i-1]][route[i]]
+				if j+1 < len(route) {
+					delta += dist[route[i]][route[j+1]] - dist[route[j]][route[j+1]]
+				}
+				if delta < -1e-6 {
+					for a, b := i, j; a < b; a, b = a+1, b-1 {
+						route[a], route[b] = route[b], route[a]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// ShopsByID returns the shops with the given ids, in that order
+func (d *DB) ShopsByID(ctx context.Context, ids []int) ([]Shop, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+d.shopColumns()+`
+		FROM quilt_shops
+		WHERE id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shops: %w", err)
+	}
+	defer rows.Close()
+
+	shops, err := scanShops(rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]Shop, len(shops))
+	for _, s := range shops {
+		byID[s.ID] = s
+	}
+
+	ordered := make([]Shop, 0, len(ids))
+	for _, id := range ids {
+		s, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("no shop with id %d", id)
+		}
+		ordered = append(ordered, s)
+	}
+	return ordered, nil
+}
+
+// ParsePoint reads a "latitude,longitude" pair such as "38.03,-78.48"
+func ParsePoint(s string) (Point, error) {
+	latText, lonText, ok := strings.Cut(s, ",")
+	if !ok {
+		return Point{}, fmt.Errorf("invalid point %q, want latitude,longitude", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return Point{}, fmt.Errorf("invalid latitude in %q", s)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return Point{}, fmt.Errorf("invalid longitude in %q", s)
+	}
+	return Point{Latitude: lat, Longitude: lon}, nil
+}
@@ -0,0 +1,111 @@
+package proximity
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestPlanTrip(t *testing.T) {
+	// Shops along the equator, handed over out of order
+	line := []Shop{
+		{ID: 3, Latitude: 0, Longitude: 3},
+		{ID: 1, Latitude: 0, Longitude: 1},
+		{ID: 4, Latitude: 0, Longitude: 4},
+		{ID: 2, Latitude: 0, Longitude: 2},
+	}
+	start := Point{Latitude: 0, Longitude: 0}
+
+	it := PlanTrip(start, line, TripOptions{})
+	if got := ids(it.Stops); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("stops = %v, want [1 2 3 4]", got)
+	}
+	if len(it.Legs) != 4 || it.Legs[0].From != nil || it.Legs[3].To.ID != 4 {
+		t.Errorf("legs = %+v", it.Legs)
+	}
+	want := Haversine(start, Point{Latitude: 0, Longitude: 4})
+	if math.Abs(float64(it.Total-want)) > 1 {
+		t.Errorf("Total = %v, want %v", it.Total, want)
+	}
+
+	round := PlanTrip(start, line, TripOptions{RoundTrip: true, RoadFactor: 1.5})
+	if len(round.Legs) != 5 || round.Legs[4].To != nil {
+		t.Errorf("round trip legs = %+v, want 5 ending at the start", round.Legs)
+	}
+	if math.Abs(float64(round.Total-3*want)) > 1 {
+		t.Errorf("round trip Total = %v, want %v", round.Total, 3*want)
+	}
+	if math.Abs(round.Legs[0].Bearing-90) > 0.01 {
+		t.Errorf("first leg bearing = %v, want due east", round.Legs[0].Bearing)
+	}
+
+	for _, opts := range []TripOptions{{}, {RoundTrip: true}} {
+		if empty := PlanTrip(start, nil, opts); len(empty.Legs) != 0 || len(empty.Stops) != 0 || empty.Total != 0 {
+			t.Errorf("empty trip with %+v = %+v, want no legs", opts, empty)
+		}
+	}
+}
+
+func TestPlanTripTwoOpt(t *testing.T) {
+	// Nearest neighbor goes 1, 2, 3 and then has to cross back over its own
+	// path to reach 4; 2-opt untangles the crossing
+	shops := []Shop{
+		{ID: 1, Latitude: 0, Longitude: 1},
+		{ID: 2, Latitude: 1, Longitude: 1.2},
+		{ID: 3, Latitude: 1, Longitude: 2.5},
+		{ID: 4, Latitude: 0, Longitude: 2.4},
+	}
+	start := Point{Latitude: 0, Longitude: 0}
+
+	it := PlanTrip(start, shops, TripOptions{RoundTrip: true})
+	points := append([]Point{start}, shopPoints(shops)...)
+	nn := nearestNeighborRoute(distanceMatrix(points))
+	var nnTotal Distance
+	route := append(nn, 0)
+	for k := 1; k < len(route); k++ {
+		nnTotal += Haversine(points[route[k-1]], points[route[k]])
+	}
+	if it.Total > nnTotal {
+		t.Errorf("2-opt Total %v is longer than nearest neighbor %v", it.Total, nnTotal)
+	}
+	if got := ids(it.Stops); !slices.Equal(got, []int{1, 4, 3, 2}) && !slices.Equal(got, []int{2, 3, 4, 1}) {
+		t.Errorf("stops = %v, want the loop 1 4 3 2 either way round", got)
+	}
+}
+
+func TestShopsByID(t *testing.T) {
+	db := newTestDB(t)
+
+	shops, err := db.ShopsByID(context.Background(), []int{4, 1})
+	if err != nil {
+		t.Fatalf("ShopsByID() error = %v", err)
+	}
+	if len(shops) != 2 || shops[0].Name != "Coastal Cottons" || shops[1].Name != "Downtown Quilts" {
+		t.Errorf("ShopsByID() = %+v, want shops 4 and 1 in order", shops)
+	}
+
+	if _, err := db.ShopsByID(context.Background(), []int{1, 99}); err == nil {
+		t.Error("ShopsByID() error = nil, want missing shop error")
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	if p, err := ParsePoint(" 38.03, -78.48 "); err != nil || p.Latitude != 38.03 || p.Longitude != -78.48 {
+		t.Errorf("ParsePoint() = %v, %v", p, err)
+	}
+	for _, bad := range []string{"", "38.03", "91,0", "0,181", "a,b"} {
+		if _, err := ParsePoint(bad); err == nil {
+			t.Errorf("ParsePoint(%q) error = nil", bad)
+		}
+	}
+}
+
+// shopPoints returns the location of each shop
+func shopPoints(shops []Shop) []Point {
+	points := make([]Point, len(shops))
+	for i, s := range shops {
+		points[i] = s.Point()
+	}
+	return points
+}
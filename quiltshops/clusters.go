@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runClusters finds the hot spots of quilt shops with DBSCAN and, with
+// -save, writes each shop's cluster to the shop_clusters table
+func runClusters(cfg *config, args []string) {
+	fs := flag.NewFlagSet("clusters", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged quilt shops database")
+	epsilonFlag := fs.String("epsilon", "10mi", "shops within this distance of each other are neighbors, like 10mi or 15km")
+	minPoints := fs.Int("min-points", 3, "neighbors, counting the shop itself, a shop needs to anchor a cluster")
+	unitsFlag := fs.String("units", "mi", "units for distances, mi or km")
+	save := fs.Bool("save", false, "write each shop's cluster to the database's shop_clusters table, replacing what's there")
+	fs.Parse(args)
+
+	unit, err := proximity.ParseUnit(*unitsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+	epsilon, err := proximity.ParseDistance(*epsilonFlag, unit)
+	if err != nil {
+		log.Fatalf("Invalid -epsilon: %v", err)
+	}
+	if *minPoints < 1 {
+		log.Fatalf("-min-points must be at least 1")
+	}
+
+	requireDatabase(*dbPath, "run quiltshops merge first")
+	db, err := proximity.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	shops, err := db.AllShops(ctx)
+	if err != nil {
+		log.Fatalf("Error loading shops: %v", err)
+	}
+
+	clusters, noise := proximity.DBSCAN(shops, epsilon, *minPoints)
+	fmt.Printf("%d clusters of quilt shops within %s %s of each other (at least %d shops), %d shops on their own\n\n",
+		len(clusters), strconv.FormatFloat(epsilon.In(unit), 'f', -1, 64), *unitsFlag, *minPoints, len(noise))
+	for _, c := range clusters {
+		fmt.Printf("Cluster %d: %d shops within %.1f %s of %s\n", c.ID, len(c.Shops), c.Radius.In(unit), *unitsFlag, c.Centroid)
+		for _, s := range c.Shops {
+			fmt.Printf("    %s - %s, %s\n", s.Name, s.City, s.State)
+		}
+		fmt.Println()
+	}
+
+	if *save {
+		if err := db.SaveClusters(ctx, clusters); err != nil {
+			log.Fatalf("Error saving clusters: %v", err)
+		}
+		log.Printf("✓ Saved %d clusters to shop_clusters", len(clusters))
+	}
+}
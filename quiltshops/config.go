@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// defaultConfigPath is the config file at the root of the repository
+const defaultConfigPath = "quiltshops.toml"
+
+// config is what quiltshops.toml declares: the sources, where the
+// databases go and how to geocode. Every command loads it; environment
+// variables override the file and flags override both.
+type config struct {
+	Paths   pathsConfig     `toml:"paths"`
+	Geocode geocodeConfig   `toml:"geocode"`
+	Sources []source.Config `toml:"sources"`
+
+	// path is the file the config came from, empty for the defaults
+	path string
+	// sources are built from the source configs, in the same order
+	sources []source.Source
+}
+
+// pathsConfig is where the shared files go
+type pathsConfig struct {
+	// Merged is the merged database the proximity commands read
+	Merged string `toml:"merged"`
+	// GeocodeCache is shared by all states so results survive rebuilds
+	GeocodeCache string `toml:"geocode_cache"`
+}
+
+// geocodeConfig is the geocoder settings
+type geocodeConfig struct {
+	// Providers are tried in order
+	Providers []providerConfig `toml:"providers"`
+	Email     string           `toml:"email"`
+	Timeout   time.Duration    `toml:"timeout"`
+	// RateLimit is the minimum gap between requests to every provider
+	// without a rate limit of its own; 0 keeps each one's usage policy
+	RateLimit time.Duration `toml:"rate_limit"`
+	Attempts  int           `toml:"attempts"`
+	Validate  bool          `toml:"validate"`
+	Offline   bool          `toml:"offline"`
+}
+
+// providerConfig is one geocoding provider in the chain
+type providerConfig struct {
+	Name string `toml:"name"`
+	// URL is a self-hosted instance to use instead of the public one
+	URL       string        `toml:"url"`
+	RateLimit time.Duration `toml:"rate_limit"`
+}
+
+// defaultConfig is the settings when there's no config file: no sources,
+// and Nominatim for geocoding
+func defaultConfig() *config {
+	return &config{
+		Paths: pathsConfig{
+			Merged:       "merge/quilt_shops.db",
+			GeocodeCache: "geocode_cache.db",
+		},
+		Geocode: geocodeConfig{
+			Providers: []providerConfig{{Name: "nominatim"}},
+			Timeout:   10 * time.Second,
+			Attempts:  geocode.DefaultRetryPolicy.MaxAttempts,
+			Validate:  true,
+		},
+	}
+}
+
+// loadConfig reads the config file at path over the defaults, applies the
+// QUILTSHOPS_ environment variables and builds the sources. A missing file
+// is only an error when required, so the commands that don't need sources
+// work without one.
+func loadConfig(path string, required bool) (*config, error) {
+	cfg := defaultConfig()
+
+	if _, err := os.Stat(path); err == nil || required {
+		md, err := toml.DecodeFile(path, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		// Catch typos, which would otherwise quietly leave a default
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return nil, fmt.Errorf("unknown settings in %s: %s", path, strings.Join(keys, ", "))
+		}
+		cfg.path = path
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.buildSources(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides settings from the environment
+func (c *config) applyEnv(lookup func(string) (string, bool)) error {
+	if v, ok := lookup("QUILTSHOPS_MERGED_DB"); ok {
+		c.Paths.Merged = v
+	}
+	if v, ok := lookup("QUILTSHOPS_GEOCODE_CACHE"); ok {
+		c.Paths.GeocodeCache = v
+	}
+	if v, ok := lookup("QUILTSHOPS_PROVIDERS"); ok {
+		c.Geocode.Providers = parseProviders(v)
+	}
+	if v, ok := lookup("QUILTSHOPS_EMAIL"); ok {
+		c.Geocode.Email = v
+	}
+	if v, ok := lookup("QUILTSHOPS_RATE_LIMIT"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid QUILTSHOPS_RATE_LIMIT: %w", err)
+		}
+		c.Geocode.RateLimit = d
+	}
+	return nil
+}
+
+// buildSources makes a source of each source config, filling in the
+// default state database. Each source needs a database of its own, since a
+// database records a single source and scraping replaces its shops.
+func (c *config) buildSources() error {
+	seen := map[string]bool{}
+	databases := map[string]string{}
+	c.sources = nil
+	for i := range c.Sources {
+		sc := &c.Sources[i]
+		src, err := source.New(*sc)
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(sc.Name)
+		if seen[name] {
+			return fmt.Errorf("source %s is declared twice", sc.Name)
+		}
+		seen[name] = true
+
+		if sc.Database == "" {
+			sc.Database = stateDatabasePath(src.State())
+		}
+		db := filepath.Clean(sc.Database)
+		if other, ok := databases[db]; ok {
+			return fmt.Errorf("sources %s and %s both use database %s, give one a database of its own", other, sc.Name, sc.Database)
+		}
+		databases[db] = sc.Name
+		c.sources = append(c.sources, src)
+	}
+	return nil
+}
+
+// source returns the named source and its config
+func (c *config) source(name string) (source.Source, source.Config, error) {
+	for i, src := range c.sources {
+		if strings.EqualFold(src.Name(), strings.TrimSpace(name)) {
+			return src, c.Sources[i], nil
+		}
+	}
+	return nil, source.Config{}, fmt.Errorf("unknown source %q (choose from %s)", name, strings.Join(c.sourceNames(), ", "))
+}
+
+// sourceNames returns the names of the sources, sorted
+func (c *config) sourceNames() []string {
+	names := make([]string, len(c.sources))
+	for i, src := range c.sources {
+		names[i] = src.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sourceStates returns the states the sources cover
+func (c *config) sourceStates() []string {
+	seen := map[string]bool{}
+	var states []string
+	for _, src := range c.sources {
+		if !seen[src.State()] {
+			seen[src.State()] = true
+			states = append(states, src.State())
+		}
+	}
+	sort.Strings(states)
+	return states
+}
+
+// databasePath returns the state database a source's shops go in
+func (c *config) databasePath(src source.Source) string {
+	_, sc, err := c.source(src.Name())
+	if err != nil || sc.Database == "" {
+		return stateDatabasePath(src.State())
+	}
+	return sc.Database
+}
+
+// noSources is the hint for commands run without any sources configured
+func (c *config) noSources() error {
+	if c.path == "" {
+		return fmt.Errorf("no sources: %s not found, run from the repository checkout or pass -config", defaultConfigPath)
+	}
+	return fmt.Errorf("no sources declared in %s", c.path)
+}
+
+// providerSpec returns the providers as a spec for geocode.New, like
+// "census,nominatim=http://localhost:8080"
+func (g geocodeConfig) providerSpec() string {
+	specs := make([]string, len(g.Providers))
+	for i, p := range g.Providers {
+		specs[i] = p.Name
+		if p.URL != "" {
+			specs[i] += "=" + p.URL
+		}
+	}
+	return strings.Join(specs, ",")
+}
+
+// parseProviders reads a provider spec, the form the -provider flag and
+// QUILTSHOPS_PROVIDERS take
+func parseProviders(spec string) []providerConfig {
+	var providers []providerConfig
+	for _, item := range strings.Split(spec, ",") {
+		name, url, _ := strings.Cut(strings.TrimSpace(item), "=")
+		if name = strings.TrimSpace(name); name != "" {
+			providers = append(providers, providerConfig{Name: name, URL: strings.TrimSpace(url)})
+		}
+	}
+	return providers
+}
+
+// geocoder returns the chain of configured providers. A provider's own
+// rate limit takes the place of the shared one.
+func (g geocodeConfig) geocoder() (geocode.Geocoder, error) {
+	var chain geocode.Chain
+	for _, p := range g.Providers {
+		spec := p.Name
+		if p.URL != "" {
+			spec += "=" + p.URL
+		}
+		client := &geocode.Client{
+			Email:     g.Email,
+			Timeout:   g.Timeout,
+			RateLimit: g.RateLimit,
+			Retry:     geocode.DefaultRetryPolicy,
+		}
+		if p.RateLimit != 0 {
+			client.RateLimit = p.RateLimit
+		}
+		client.Retry.MaxAttempts = g.Attempts
+
+		geocoder, err := geocode.New(spec, client)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, geocoder)
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, fmt.Errorf("no geocoding provider configured")
+	case 1:
+		return chain[0], nil
+	}
+	return chain, nil
+}
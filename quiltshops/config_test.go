@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	_ "github.com/chicks-net/quilt-shop-proximity/source/csvsource"
+	_ "github.com/chicks-net/quilt-shop-proximity/source/htmlsource"
+	_ "github.com/chicks-net/quilt-shop-proximity/source/pdfsource"
+)
+
+// writeConfig writes a config file to a temporary directory
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quiltshops.toml")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRepositoryConfig(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join("..", defaultConfigPath), true)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if got := cfg.sourceStates(); !reflect.DeepEqual(got, []string{"CA", "VA"}) {
+		t.Errorf("sourceStates() = %v, want [CA VA]", got)
+	}
+	for _, src := range cfg.sources {
+		if path := cfg.databasePath(src); path != stateDatabasePath(src.State()) {
+			t.Errorf("%s database = %s, want %s", src.Name(), path, stateDatabasePath(src.State()))
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+[paths]
+merged = "out/merged.db"
+
+[geocode]
+timeout = "30s"
+validate = false
+
+[[geocode.providers]]
+name = "census"
+
+[[geocode.providers]]
+name = "nominatim"
+url = "http://localhost:8080"
+rate_limit = "250ms"
+
+[[sources]]
+name = "guild"
+type = "csv"
+state = "Oregon"
+path = "oregon.csv"
+
+[sources.options]
+name_column = "shop"
+`)
+
+	cfg, err := loadConfig(path, true)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.Paths.Merged != "out/merged.db" || cfg.Paths.GeocodeCache != "geocode_cache.db" {
+		t.Errorf("Paths = %+v, want the merged path set and the cache default", cfg.Paths)
+	}
+	if cfg.Geocode.Timeout != 30*time.Second || cfg.Geocode.Validate || cfg.Geocode.Offline {
+		t.Errorf("Geocode = %+v, want the file's timeout and validate over the defaults", cfg.Geocode)
+	}
+	if got := cfg.Geocode.providerSpec(); got != "census,nominatim=http://localhost:8080" {
+		t.Errorf("providerSpec() = %q", got)
+	}
+	if len(cfg.sources) != 1 || cfg.sources[0].State() != "OR" {
+		t.Fatalf("sources = %v, want the Oregon guild", cfg.sources)
+	}
+	if got := cfg.databasePath(cfg.sources[0]); got != "shops-in-oregon/quilt_shops.db" {
+		t.Errorf("databasePath() = %q, want the state default", got)
+	}
+
+	geocoder, err := cfg.Geocode.geocoder()
+	if err != nil {
+		t.Fatalf("geocoder() error = %v", err)
+	}
+	if chain, ok := geocoder.(geocode.Chain); !ok || len(chain) != 2 {
+		t.Errorf("geocoder() = %T, want a chain of two", geocoder)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"unknown setting", "[paths]\nmerge = \"merged.db\"\n"},
+		{"bad duration", "[geocode]\ntimeout = \"soon\"\n"},
+		{"unknown source type", "[[sources]]\nname = \"guild\"\ntype = \"xls\"\nstate = \"OR\"\n"},
+		{"unknown option", "[[sources]]\nname = \"guild\"\ntype = \"csv\"\nstate = \"OR\"\npath = \"a.csv\"\n[sources.options]\nzip = \"zip\"\n"},
+		{"duplicate source", "[[sources]]\nname = \"guild\"\ntype = \"csv\"\nstate = \"OR\"\npath = \"a.csv\"\n" +
+			"[[sources]]\nname = \"Guild\"\ntype = \"csv\"\nstate = \"WA\"\npath = \"b.csv\"\n"},
+		{"shared database", "[[sources]]\nname = \"guild\"\ntype = \"csv\"\nstate = \"OR\"\npath = \"a.csv\"\n" +
+			"[[sources]]\nname = \"shops\"\ntype = \"csv\"\nstate = \"OR\"\npath = \"b.csv\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadConfig(writeConfig(t, tt.text), true); err == nil {
+				t.Error("loadConfig() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultConfigPath)
+
+	cfg, err := loadConfig(path, false)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if len(cfg.sources) != 0 || cfg.Paths.Merged != defaultConfig().Paths.Merged || cfg.noSources() == nil {
+		t.Errorf("loadConfig() = %+v, want the defaults with no sources", cfg)
+	}
+
+	if _, err := loadConfig(path, true); err == nil {
+		t.Error("loadConfig() of a required missing file error = nil, want an error")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"QUILTSHOPS_MERGED_DB":  "/tmp/merged.db",
+		"QUILTSHOPS_PROVIDERS":  "census, nominatim=http://localhost:8080",
+		"QUILTSHOPS_EMAIL":      "quilter@example.com",
+		"QUILTSHOPS_RATE_LIMIT": "2s",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cfg := defaultConfig()
+	if err := cfg.applyEnv(lookup); err != nil {
+		t.Fatalf("applyEnv() error = %v", err)
+	}
+	want := []providerConfig{{Name: "census"}, {Name: "nominatim", URL: "http://localhost:8080"}}
+	if !reflect.DeepEqual(cfg.Geocode.Providers, want) {
+		t.Errorf("Providers = %+v, want %+v", cfg.Geocode.Providers, want)
+	}
+	if cfg.Paths.Merged != "/tmp/merged.db" || cfg.Paths.GeocodeCache != "geocode_cache.db" ||
+		cfg.Geocode.Email != "quilter@example.com" || cfg.Geocode.RateLimit != 2*time.Second {
+		t.Errorf("config = %+v, want the environment over the defaults", cfg)
+	}
+
+	env["QUILTSHOPS_RATE_LIMIT"] = "fast"
+	if err := defaultConfig().applyEnv(lookup); err == nil {
+		t.Error("applyEnv() error = nil, want an invalid duration error")
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runExport writes the shops in the merged database as CSV, JSON or GeoJSON
+func runExport(cfg *config, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged quilt shops database")
+	format := fs.String("format", "csv", "output format: csv, json or geojson")
+	state := fs.String("state", "", "only shops in this state, like VA")
+	output := fs.String("o", "", "write to this file instead of standard output")
+	fs.Parse(args)
+
+	if *format != "csv" && *format != "json" && *format != "geojson" {
+		log.Fatalf("Unknown -format %q (use csv, json or geojson)", *format)
+	}
+
+	requireDatabase(*dbPath, "run quiltshops merge first")
+	db, err := proximity.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	all, err := db.AllShops(context.Background())
+	if err != nil {
+		log.Fatalf("Error reading shops: %v", err)
+	}
+	shops := all[:0]
+	for _, s := range all {
+		if *state == "" || strings.EqualFold(s.State, *state) {
+			shops = append(shops, s)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if *output != "" {
+		if f, err = os.Create(*output); err != nil {
+			log.Fatalf("Error creating %s: %v", *output, err)
+		}
+		w = f
+	}
+
+	switch *format {
+	case "csv":
+		err = exportCSV(w, shops)
+	case "json":
+		err = exportJSON(w, shops)
+	case "geojson":
+		err = exportGeoJSON(w, shops)
+	}
+	if err != nil {
+		log.Fatalf("Error writing shops: %v", err)
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			log.Fatalf("Error writing %s: %v", *output, err)
+		}
+		log.Printf("✓ Wrote %d shops to %s", len(shops), *output)
+	}
+}
+
+// exportCSV writes one row per shop under a header
+func exportCSV(w io.Writer, shops []proximity.Shop) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "name", "address", "city", "state", "phone", "email", "website", "latitude", "longitude", "approximate"})
+	for _, s := range shops {
+		out.Write([]string{strconv.Itoa(s.ID), s.Name, s.Address, s.City, s.State, s.Phone, s.Email, s.Website,
+			strconv.FormatFloat(s.Latitude, 'f', -1, 64), strconv.FormatFloat(s.Longitude, 'f', -1, 64),
+			strconv.FormatBool(s.Approximate)})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// shopProperties are the fields of a shop as JSON. approximate marks a
+// pin at the middle of the shop's town or ZIP code.
+func shopProperties(s proximity.Shop) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          s.ID,
+		"name":        s.Name,
+		"address":     s.Address,
+		"city":        s.City,
+		"state":       s.State,
+		"phone":       s.Phone,
+		"email":       s.Email,
+		"website":     s.Website,
+		"approximate": s.Approximate,
+	}
+}
+
+// exportJSON writes the shops as an array of objects
+func exportJSON(w io.Writer, shops []proximity.Shop) error {
+	records := make([]map[string]interface{}, len(shops))
+	for i, s := range shops {
+		records[i] = shopProperties(s)
+		records[i]["latitude"] = s.Latitude
+		records[i]["longitude"] = s.Longitude
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// exportGeoJSON writes the shops as a layer of points for map tools
+func exportGeoJSON(w io.Writer, shops []proximity.Shop) error {
+	features := make([]map[string]interface{}, len(shops))
+	for i, s := range shops {
+		features[i] = map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{s.Longitude, s.Latitude},
+			},
+			"properties": shopProperties(s),
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"type": "FeatureCollection", "features": features})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runGaps finds "quilt shop deserts", the places in each state farthest
+// from any quilt shop
+func runGaps(cfg *config, args []string) {
+	fs := flag.NewFlagSet("gaps", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged quilt shops database")
+	statesFlag := fs.String("states", "", "comma separated states to check (default every state with shops)")
+	thresholdFlag := fs.String("threshold", "30mi", "report places with no shop this close, like 30mi or 50km")
+	spacingFlag := fs.String("spacing", "10mi", "distance between grid points")
+	places := fs.Bool("places", false, "check the bundled gazetteer's towns instead of a grid")
+	unitsFlag := fs.String("units", "mi", "units for distances, mi or km")
+	n := fs.Int("n", 20, "number of places to list, 0 for all")
+	geojsonPath := fs.String("geojson", "", "also write every gap as a GeoJSON layer to this file")
+	fs.Parse(args)
+
+	unit, err := proximity.ParseUnit(*unitsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+	threshold, err := proximity.ParseDistance(*thresholdFlag, unit)
+	if err != nil {
+		log.Fatalf("Invalid -threshold: %v", err)
+	}
+	spacing, err := proximity.ParseDistance(*spacingFlag, unit)
+	if err != nil || spacing <= 0 {
+		log.Fatalf("Invalid -spacing %q", *spacingFlag)
+	}
+
+	requireDatabase(*dbPath, "run quiltshops merge first")
+	db, err := proximity.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	var states []string
+	if *statesFlag != "" {
+		states = strings.Split(*statesFlag, ",")
+	} else if states, err = db.States(ctx); err != nil {
+		log.Fatalf("Error listing states: %v", err)
+	}
+
+	var gazetteer *geocode.Offline
+	if *places {
+		if gazetteer, err = geocode.NewOffline(); err != nil {
+			log.Fatalf("Error loading gazetteer: %v", err)
+		}
+	}
+
+	var candidates []proximity.Place
+	for _, state := range states {
+		state = strings.TrimSpace(state)
+		box, ok := geocode.StateBounds(state)
+		if !ok {
+			log.Printf("⚠ Unknown state %q, skipping", state)
+			continue
+		}
+
+		if gazetteer == nil {
+			code := geocode.StateCode(state)
+			grid := inState(code, proximity.GridPlaces(proximity.Box(box), spacing, code))
+			log.Printf("Checking %d grid points across %s", len(grid), code)
+			candidates = append(candidates, grid...)
+			continue
+		}
+		towns := gazetteer.Places(state)
+		if len(towns) == 0 {
+			log.Printf("⚠ The gazetteer has no places in %s; run `just gazetteer` to fill it in", state)
+		}
+		for _, town := range towns {
+			candidates = append(candidates, proximity.Place{
+				Name:  town.Name,
+				State: town.State,
+				Point: proximity.Point{Latitude: town.Coords.Latitude, Longitude: town.Coords.Longitude},
+			})
+		}
+	}
+
+	gaps, err := db.Gaps(ctx, candidates, threshold)
+	if err != nil {
+		log.Fatalf("Error finding gaps: %v", err)
+	}
+
+	fmt.Printf("%d of %d places are more than %s %s from a quilt shop\n\n", len(gaps), len(candidates),
+		strconv.FormatFloat(threshold.In(unit), 'f', -1, 64), *unitsFlag)
+	for i, g := range gaps {
+		if *n > 0 && i >= *n {
+			fmt.Printf("     ... and %d more\n", len(gaps)-i)
+			break
+		}
+		fmt.Printf("%3d. %6.1f %s  %s, %s  (nearest: %s - %s, %s)\n", i+1, g.Nearest.Distance.In(unit), *unitsFlag,
+			g.Name, g.State, g.Nearest.Name, g.Nearest.City, g.Nearest.State)
+	}
+
+	if *geojsonPath != "" {
+		if err := writeGapsGeoJSON(*geojsonPath, gaps, unit, *unitsFlag); err != nil {
+			log.Fatalf("Error writing GeoJSON: %v", err)
+		}
+		log.Printf("✓ Wrote %d gaps to %s", len(gaps), *geojsonPath)
+	}
+}
+
+// inState keeps the grid points inside the state's outline, dropping the
+// corners of its bounding box that are in a neighboring state or offshore.
+// States without an outline keep the whole box.
+func inState(state string, grid []proximity.Place) []proximity.Place {
+	var inside []proximity.Place
+	for _, p := range grid {
+		in, ok := geocode.InsideOutline(state, geocode.Coordinates{Latitude: p.Point.Latitude, Longitude: p.Point.Longitude})
+		if !ok {
+			log.Printf("⚠ No outline for %s, checking its whole bounding box", state)
+			return grid
+		}
+		if in {
+			inside = append(inside, p)
+		}
+	}
+	return inside
+}
+
+// writeGapsGeoJSON saves the gaps as a layer of points, ranked farthest first
+func writeGapsGeoJSON(path string, gaps []proximity.Gap, unit proximity.Distance, units string) error {
+	features := make([]map[string]interface{}, len(gaps))
+	for i, g := range gaps {
+		features[i] = map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{g.Point.Longitude, g.Point.Latitude},
+			},
+			"properties": map[string]interface{}{
+				"rank":             i + 1,
+				"name":             g.Name,
+				"state":            g.State,
+				"nearest_shop_id":  g.Nearest.ID,
+				"nearest_shop":     g.Nearest.Name,
+				"nearest_" + units: g.Nearest.Distance.In(unit),
+			},
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]interface{}{"type": "FeatureCollection", "features": features}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// runGeocode adds coordinates to the shops in the chosen state databases,
+// one request per shop or, with -batch, one Census upload per state
+func runGeocode(cfg *config, args []string) {
+	fs := flag.NewFlagSet("geocode", flag.ExitOnError)
+	sel := addSelectionFlags(fs, cfg)
+	provider := fs.String("provider", cfg.Geocode.providerSpec(),
+		"geocoding provider, or comma separated providers to try in order ("+strings.Join(geocode.Providers(), ", ")+
+			"); add =URL to use a self-hosted instance, like nominatim=http://localhost:8080")
+	cachePath := fs.String("cache", cfg.Paths.GeocodeCache,
+		"SQLite file for caching geocoding results across runs and states (empty to disable)")
+	attempts := fs.Int("attempts", cfg.Geocode.Attempts,
+		"tries per address when a provider is rate limiting, erroring or unreachable")
+	email := fs.String("email", cfg.Geocode.Email, "contact email sent to providers that ask for one")
+	timeout := fs.Duration("timeout", cfg.Geocode.Timeout, "time limit for each request")
+	rateLimit := fs.Duration("rate-limit", cfg.Geocode.RateLimit,
+		"minimum gap between requests to every provider (default is the config's, or else each provider's usage policy)")
+	validate := fs.Bool("validate", cfg.Geocode.Validate,
+		"ask for several matches and skip those outside the shop's state or city")
+	offline := fs.Bool("offline", cfg.Geocode.Offline,
+		"place shops no provider can find at their ZIP code or city centroid from the bundled gazetteer (run `just gazetteer` first)")
+	batch := fs.Bool("batch", false, "send every shop to the Census batch geocoder in one upload instead")
+	censusURL := fs.String("url", "", "with -batch, Census geocoder root, to use a local stand-in (default the public service)")
+	fs.Parse(args)
+
+	sources, err := sel.sources()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *batch {
+		var client *geocode.Client
+		if *censusURL != "" {
+			client = geocode.NewClient(strings.TrimSuffix(*censusURL, "/"))
+		}
+		census := geocode.NewCensus(client)
+
+		for _, src := range sources {
+			path := sel.databasePath(src)
+			requireDatabase(path, "run quiltshops scrape first")
+			log.Printf("Starting Census batch geocoding for %s...", src.State())
+			if err := source.GeocodeShopsBatch(ctx, path, src, census); err != nil {
+				log.Fatalf("Error geocoding shops: %v", err)
+			}
+		}
+		log.Println("Geocoding complete!")
+		return
+	}
+
+	// Flags override the config; a -provider list replaces the configured
+	// chain, and -rate-limit every provider's own limit
+	settings := cfg.Geocode
+	settings.Email, settings.Timeout, settings.Attempts = *email, *timeout, *attempts
+	settings.Providers = append([]providerConfig(nil), settings.Providers...)
+	if *provider != settings.providerSpec() {
+		settings.Providers = parseProviders(*provider)
+	}
+	if *rateLimit != settings.RateLimit {
+		settings.RateLimit = *rateLimit
+		for i := range settings.Providers {
+			settings.Providers[i].RateLimit = 0
+		}
+	}
+	geocoder, err := settings.geocoder()
+	if err != nil {
+		log.Fatalf("Error choosing geocoder: %v", err)
+	}
+
+	if *validate {
+		geocoder = geocode.Validate(geocoder)
+	}
+
+	if *cachePath != "" {
+		cacheDB, err := sql.Open("sqlite", *cachePath)
+		if err != nil {
+			log.Fatalf("Error opening geocode cache: %v", err)
+		}
+		defer cacheDB.Close()
+
+		cache, err := geocode.NewCache(cacheDB)
+		if err != nil {
+			log.Fatalf("Error opening geocode cache: %v", err)
+		}
+		// The cache knows a validating geocoder and only hands it answers
+		// that were validated too
+		geocoder = cache.Wrap(geocoder, *provider)
+	}
+
+	var fallback geocode.Geocoder
+	if *offline {
+		gazetteer, err := geocode.NewOffline()
+		if err != nil {
+			log.Fatalf("Error loading gazetteer: %v", err)
+		}
+		fallback = gazetteer
+	}
+
+	for _, src := range sources {
+		path := sel.databasePath(src)
+		requireDatabase(path, "run quiltshops scrape first")
+		log.Printf("Starting geocoding process for %s...", src.State())
+		if err := source.GeocodeShops(ctx, path, src, geocoder, fallback); err != nil {
+			log.Fatalf("Error geocoding shops: %v", err)
+		}
+	}
+	log.Println("Geocoding complete!")
+}
+
+// runValidate reverse geocodes the shops in the chosen state databases and
+// reports those whose coordinates land somewhere else
+func runValidate(cfg *config, args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	sel := addSelectionFlags(fs, cfg)
+	provider := fs.String("provider", cfg.Geocode.providerSpec(),
+		"reverse geocoding provider, or comma separated providers to try in order ("+strings.Join(geocode.Providers(), ", ")+
+			"); add =URL to use a self-hosted instance, like nominatim=http://localhost:8080")
+	email := fs.String("email", cfg.Geocode.Email, "contact email sent to providers that ask for one")
+	timeout := fs.Duration("timeout", cfg.Geocode.Timeout, "time limit for each request")
+	fs.Parse(args)
+
+	sources, err := sel.sources()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	geocoder, err := geocode.New(*provider, &geocode.Client{Email: *email, Timeout: *timeout})
+	if err != nil {
+		log.Fatalf("Error choosing geocoder: %v", err)
+	}
+	reverse, ok := geocoder.(geocode.ReverseGeocoder)
+	if !ok {
+		log.Fatalf("Geocoding provider %s can't reverse geocode", *provider)
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, src := range sources {
+		path := sel.databasePath(src)
+		requireDatabase(path, "run quiltshops scrape first")
+		log.Printf("Checking %s shop coordinates...", src.State())
+		if err := source.ValidateShops(ctx, path, src, reverse); err != nil {
+			log.Fatalf("Error validating shops: %v", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+module github.com/chicks-net/quilt-shop-proximity/quiltshops
+
+go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/chicks-net/quilt-shop-proximity/geocode v0.0.0
+	github.com/chicks-net/quilt-shop-proximity/merge v0.0.0
+	github.com/chicks-net/quilt-shop-proximity/proximity v0.0.0
+	github.com/chicks-net/quilt-shop-proximity/source v0.0.0
+	modernc.org/sqlite v1.34.2
+)
+
+require (
+	github.com/PuerkitoBio/goquery v1.8.1 // indirect
+	github.com/andybalholm/cascadia v1.3.1 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/ledongthuc/pdf v0.0.0-20240201131950-da5b75280b06 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/net v0.7.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	modernc.org/gc/v3 v3.0.0-20240107210532-573471604cb6 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+	modernc.org/strutil v1.2.0 // indirect
+	modernc.org/token v1.1.0 // indirect
+)
+
+replace (
+	github.com/chicks-net/quilt-shop-proximity/geocode => ../geocode
+	github.com/chicks-net/quilt-shop-proximity/merge => ../merge
+	github.com/chicks-net/quilt-shop-proximity/proximity => ../proximity
+	github.com/chicks-net/quilt-shop-proximity/source => ../source
+)
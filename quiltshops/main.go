@@ -0,0 +1,92 @@
+// Command quiltshops builds and searches the quilt shop databases. It
+// scrapes each state's list, geocodes and checks the shops, merges the
+// states into one database and answers questions about it.
+//
+// The sources, paths and geocoder settings come from quiltshops.toml at the
+// root of the repository checkout; run it from there or point -C at it.
+// Paths in the config are relative to the directory it runs in.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/chicks-net/quilt-shop-proximity/source/csvsource"
+	_ "github.com/chicks-net/quilt-shop-proximity/source/htmlsource"
+	_ "github.com/chicks-net/quilt-shop-proximity/source/pdfsource"
+)
+
+// command is a quiltshops subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config, args []string)
+}
+
+// commands lists the subcommands in the order they're usually run
+var commands = []command{
+	{"scrape", "fetch each source's list into its state database", runScrape},
+	{"geocode", "add coordinates to the shops in the state databases", runGeocode},
+	{"validate", "check shop coordinates land in the listed city and state", runValidate},
+	{"merge", "combine the state databases into one with the geocoded shops", runMerge},
+	{"stats", "count the shops in a database by state or city", runStats},
+	{"city", "list the shops in a city", runCity},
+	{"near", "list the shops closest to a latitude and longitude", runNear},
+	{"plan", "order shops into a short road trip", runPlan},
+	{"route", "list the shops along a route in driving order", runRoute},
+	{"clusters", "find the hot spots of shops with DBSCAN", runClusters},
+	{"gaps", "list the places farthest from any shop", runGaps},
+	{"export", "write the merged shops as CSV, JSON or GeoJSON", runExport},
+}
+
+func main() {
+	dir := flag.String("C", "", "change to this directory before doing anything, like the repository checkout")
+	configPath := flag.String("config", defaultConfigPath, "config file declaring the sources, paths and geocoders (env QUILTSHOPS_CONFIG)")
+	flag.Usage = usage
+	flag.Parse()
+
+	// The environment names the config unless the flag does
+	configSet := false
+	flag.Visit(func(f *flag.Flag) { configSet = configSet || f.Name == "config" })
+	if env, ok := os.LookupEnv("QUILTSHOPS_CONFIG"); ok && !configSet {
+		*configPath, configSet = env, true
+	}
+
+	if *dir != "" {
+		if err := os.Chdir(*dir); err != nil {
+			log.Fatalf("Error changing directory: %v", err)
+		}
+	}
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, c := range commands {
+		if c.name == name {
+			cfg, err := loadConfig(*configPath, configSet)
+			if err != nil {
+				log.Fatalf("Error loading config: %v", err)
+			}
+			c.run(cfg, flag.Args()[1:])
+			return
+		}
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// usage lists the subcommands and global flags
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: quiltshops [-C dir] [-config file] command [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(out, "\nRun quiltshops command -h for the command's flags.\n\n")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chicks-net/quilt-shop-proximity/merge"
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runMerge combines state databases into the merged database: those given
+// with -in or -glob, or by default the database of every configured source
+func runMerge(cfg *config, args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged database to write, replacing any that's there")
+	geohashPrecision := fs.Int("geohash-precision", merge.DefaultGeohashPrecision,
+		fmt.Sprintf("characters of geohash to store for each shop (1-%d)", proximity.MaxGeohashPrecision))
+	var paths []string
+	fs.Func("in", "state database to merge instead of the configured sources' (repeatable)", func(path string) error {
+		paths = append(paths, path)
+		return nil
+	})
+	dataVersion := fs.String("data-version", "", "version to record for this edition of the data (default the date, like 2025.03.01)")
+	glob := fs.String("glob", "", "merge the state databases matching this pattern instead, like 'shops-in-*/quilt_shops.db'")
+	fs.Parse(args)
+
+	inputs, err := mergeInputs(cfg, paths, *glob)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	build := merge.Build{DataVersion: *dataVersion, Commit: gitCommit(), Generator: generator()}
+	summaries, err := merge.Merge(*dbPath, inputs, *geohashPrecision, build)
+	if err != nil {
+		log.Fatalf("Failed to merge databases: %v", err)
+	}
+
+	total := printMergeSummary(os.Stdout, summaries)
+	fmt.Printf("\n✅ Total shops in merged database: %d\n", total)
+	fmt.Println("✅ Database optimized with VACUUM")
+
+	fmt.Printf("\n🎉 Successfully created merged database at: %s\n", *dbPath)
+}
+
+// mergeInputs returns the state databases to merge. Those named on the
+// command line have to record their state themselves; for the configured
+// sources, the config's state stands in for databases that don't.
+func mergeInputs(cfg *config, paths []string, glob string) ([]merge.Input, error) {
+	if glob != "" {
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -glob: %w", err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no databases match %s", glob)
+		}
+		paths = append(paths, matches...)
+	}
+
+	var inputs []merge.Input
+	seen := map[string]bool{}
+	add := func(input merge.Input) {
+		key := filepath.Clean(input.Path)
+		if !seen[key] {
+			seen[key] = true
+			inputs = append(inputs, input)
+		}
+	}
+
+	if len(paths) > 0 {
+		for _, path := range paths {
+			add(merge.Input{Path: path})
+		}
+		return inputs, nil
+	}
+
+	if len(cfg.sources) == 0 {
+		return nil, cfg.noSources()
+	}
+	for _, src := range cfg.sources {
+		add(merge.Input{Path: cfg.databasePath(src), State: src.State(), Source: src.Name()})
+	}
+	return inputs, nil
+}
+
+// printMergeSummary writes a row per merged database with its source,
+// state and counts, and returns the total shops merged
+func printMergeSummary(out io.Writer, summaries []merge.Summary) int {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "source\tstate\tmerged\tskipped\tdatabase")
+	total, skipped := 0, 0
+	for _, s := range summaries {
+		source := s.Source
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", source, s.State, s.Merged, s.Skipped, s.Path)
+		total += s.Merged
+		skipped += s.Skipped
+	}
+	fmt.Fprintf(w, "total\t\t%d\t%d\t\n", total, skipped)
+	w.Flush()
+	return total
+}
+
+// gitCommit returns the commit of the checkout the merge runs in, or else
+// the one the binary was built from, so the database says what code built
+// it; empty if neither is known
+func gitCommit() string {
+	if out, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
+
+// generator names this program and its module version for the metadata
+func generator() string {
+	version := "devel"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	return "quiltshops " + version
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chicks-net/quilt-shop-proximity/merge"
+)
+
+func TestMergeInputs(t *testing.T) {
+	cfg, err := loadConfig(writeConfig(t, `
+[[sources]]
+name = "guild"
+type = "csv"
+state = "OR"
+path = "oregon.csv"
+
+[[sources]]
+name = "shops"
+type = "csv"
+state = "OR"
+path = "more.csv"
+database = "shops-in-oregon/more.db"
+
+[[sources]]
+name = "vcq"
+type = "pdf"
+state = "VA"
+path = "vcq.pdf"
+database = "va.db"
+`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := mergeInputs(cfg, nil, "")
+	if err != nil {
+		t.Fatalf("mergeInputs() error = %v", err)
+	}
+	want := []merge.Input{
+		{Path: "shops-in-oregon/quilt_shops.db", State: "OR", Source: "guild"},
+		{Path: "shops-in-oregon/more.db", State: "OR", Source: "shops"},
+		{Path: "va.db", State: "VA", Source: "vcq"},
+	}
+	if !reflect.DeepEqual(inputs, want) {
+		t.Errorf("mergeInputs() = %+v, want %+v", inputs, want)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.db", "b.db"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b := filepath.Join(dir, "a.db"), filepath.Join(dir, "b.db")
+	inputs, err = mergeInputs(cfg, []string{b}, filepath.Join(dir, "*.db"))
+	if err != nil {
+		t.Fatalf("mergeInputs() error = %v", err)
+	}
+	if want := []merge.Input{{Path: b}, {Path: a}}; !reflect.DeepEqual(inputs, want) {
+		t.Errorf("mergeInputs() = %+v, want %+v", inputs, want)
+	}
+
+	if _, err := mergeInputs(cfg, nil, filepath.Join(dir, "*.sqlite")); err == nil {
+		t.Error("mergeInputs() error = nil, want an error for a glob matching nothing")
+	}
+}
+
+func TestPrintMergeSummary(t *testing.T) {
+	var out bytes.Buffer
+	total := printMergeSummary(&out, []merge.Summary{
+		{Path: "ca.db", Source: "ribbiter", State: "CA", Merged: 32},
+		{Path: "va.db", State: "VA", Merged: 28, Skipped: 19},
+	})
+	if total != 60 {
+		t.Errorf("printMergeSummary() = %d, want 60", total)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"source    state  merged  skipped  database",
+		"ribbiter  CA     32      0        ca.db",
+		"-         VA     28      19       va.db",
+		"total            60      19",
+	}
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("printMergeSummary() wrote\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
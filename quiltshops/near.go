@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runNear lists the shops in the merged database closest to a latitude and
+// longitude, every shop within a radius of it a page at a time, or the
+// shops in the geohash cells around it
+func runNear(cfg *config, args []string) {
+	fs := flag.NewFlagSet("near", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged quilt shops database")
+	n := fs.Int("n", 10, "number of shops to list, or shops per page with -radius")
+	radiusFlag := fs.String("radius", "", "list every shop within this distance, like 50mi or 80km")
+	unitsFlag := fs.String("units", "mi", "units for distances, mi or km")
+	state := fs.String("state", "", "with -radius, only shops in this state, like VA")
+	city := fs.String("city", "", "with -radius, only shops in this city")
+	page := fs.Int("page", 1, "page of -radius results to show")
+	neighborhood := fs.Int("neighborhood", 0, "list the shops in the 9 geohash cells around the point at this precision, like 4")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: quiltshops near [flags] LATITUDE LONGITUDE\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	lat, err := strconv.ParseFloat(fs.Arg(0), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		log.Fatalf("Invalid latitude %q", fs.Arg(0))
+	}
+	lon, err := strconv.ParseFloat(fs.Arg(1), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		log.Fatalf("Invalid longitude %q", fs.Arg(1))
+	}
+	unit, err := proximity.ParseUnit(*unitsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+	if *n < 1 || *page < 1 {
+		log.Fatalf("-n and -page must be at least 1")
+	}
+	if *radiusFlag == "" && (*state != "" || *city != "") {
+		log.Fatalf("-state and -city need -radius")
+	}
+	if *radiusFlag != "" && *neighborhood > 0 {
+		log.Fatalf("-radius and -neighborhood can't be used together")
+	}
+
+	requireDatabase(*dbPath, "run quiltshops merge first")
+	db, err := proximity.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	from := proximity.Point{Latitude: lat, Longitude: lon}
+	var matches []proximity.Match
+	first := 1
+
+	if *radiusFlag != "" {
+		radius, err := proximity.ParseDistance(*radiusFlag, unit)
+		if err != nil {
+			log.Fatalf("Invalid -radius: %v", err)
+		}
+		result, err := db.WithinRadius(context.Background(), proximity.RadiusQuery{
+			Center: from,
+			Radius: radius,
+			Filter: proximity.Filter{State: *state, City: *city},
+			Offset: (*page - 1) * *n,
+			Limit:  *n,
+		})
+		if err != nil {
+			log.Fatalf("Error finding shops: %v", err)
+		}
+
+		pages := (result.Total + *n - 1) / *n
+		fmt.Printf("%d quilt shops within %s %s of %s", result.Total,
+			strconv.FormatFloat(radius.In(unit), 'f', -1, 64), *unitsFlag, from)
+		if pages > 1 {
+			fmt.Printf(" (page %d of %d)", *page, pages)
+		}
+		fmt.Print("\n\n")
+		matches = result.Matches
+		first = (*page-1)*(*n) + 1
+	} else if *neighborhood > 0 {
+		matches, err = db.Neighborhood(context.Background(), from, *neighborhood)
+		if err != nil {
+			log.Fatalf("Error finding shops: %v", err)
+		}
+		if len(matches) > 0 {
+			fmt.Printf("%d quilt shops around geohash %s:\n\n", len(matches), proximity.Geohash(from, *neighborhood))
+		}
+	} else {
+		matches, err = db.Nearest(context.Background(), from, *n)
+		if err != nil {
+			log.Fatalf("Error finding shops: %v", err)
+		}
+		if len(matches) > 0 {
+			fmt.Printf("Nearest %d quilt shops to %s:\n\n", len(matches), from)
+		}
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("No quilt shops found")
+		return
+	}
+
+	for i, m := range matches {
+		fmt.Printf("%3d. %7.1f %s %-2s  %s - %s, %s\n", first+i, m.Distance.In(unit), *unitsFlag,
+			proximity.CompassPoint(m.Bearing), m.Name, m.City, m.State)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// stateDatabasePath is where a state's scraped shops are kept unless the
+// source's config says otherwise, like shops-in-virginia/quilt_shops.db
+func stateDatabasePath(state string) string {
+	name := strings.ReplaceAll(strings.ToLower(geocode.StateName(state)), " ", "-")
+	return filepath.Join("shops-in-"+name, "quilt_shops.db")
+}
+
+// selection is the -state, -source and -db flags the per-state commands
+// share to pick what they work on
+type selection struct {
+	cfg    *config
+	state  string
+	source string
+	db     string
+}
+
+// addSelectionFlags adds the selection flags to fs
+func addSelectionFlags(fs *flag.FlagSet, cfg *config) *selection {
+	s := &selection{cfg: cfg}
+	fs.StringVar(&s.state, "state", "", "only the sources for this state, like VA (default every state)")
+	fs.StringVar(&s.source, "source", "", "only this source ("+strings.Join(cfg.sourceNames(), ", ")+")")
+	fs.StringVar(&s.db, "db", "", "state database to use instead of the source's, with one source")
+	return s
+}
+
+// sources returns the chosen sources, every configured source by default
+func (s *selection) sources() ([]source.Source, error) {
+	if len(s.cfg.sources) == 0 {
+		return nil, s.cfg.noSources()
+	}
+
+	var chosen []source.Source
+	if s.source != "" {
+		src, _, err := s.cfg.source(s.source)
+		if err != nil {
+			return nil, err
+		}
+		chosen = append(chosen, src)
+	} else {
+		chosen = append(chosen, s.cfg.sources...)
+	}
+
+	if s.state != "" {
+		code := geocode.StateCode(s.state)
+		if code == "" {
+			return nil, fmt.Errorf("unknown state %q", s.state)
+		}
+		var inState []source.Source
+		for _, src := range chosen {
+			if src.State() == code {
+				inState = append(inState, src)
+			}
+		}
+		if len(inState) == 0 {
+			return nil, fmt.Errorf("no source for %s (sources cover %s)", code, strings.Join(s.cfg.sourceStates(), ", "))
+		}
+		chosen = inState
+	}
+
+	if s.db != "" && len(chosen) > 1 {
+		return nil, fmt.Errorf("-db needs a single source, pick one with -state or -source")
+	}
+	return chosen, nil
+}
+
+// databasePath returns the state database for a chosen source
+func (s *selection) databasePath(src source.Source) string {
+	if s.db != "" {
+		return s.db
+	}
+	return s.cfg.databasePath(src)
+}
+
+// requireDatabase stops with a hint when a database hasn't been built yet,
+// since opening a missing SQLite file quietly creates an empty one
+func requireDatabase(path, hint string) {
+	if _, err := os.Stat(path); err != nil {
+		log.Fatalf("Error opening database: %v (%s)", err, hint)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runPlan orders a set of quilt shops into a short road trip
+func runPlan(cfg *config, args []string) {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged quilt shops database")
+	startFlag := fs.String("start", "", "where the trip starts, as latitude,longitude (required)")
+	shopsFlag := fs.String("shops", "", "comma separated shop ids to visit")
+	radiusFlag := fs.String("radius", "", "visit every shop within this distance of the start, like 50mi")
+	state := fs.String("state", "", "with -radius, only shops in this state")
+	city := fs.String("city", "", "with -radius, only shops in this city")
+	maxStops := fs.Int("max", 25, "with -radius, visit at most this many of the nearest shops")
+	roundTrip := fs.Bool("round-trip", false, "return to the start at the end")
+	roadFactor := fs.Float64("road-factor", 1, "multiply straight-line distances by this to estimate driving, like 1.3")
+	unitsFlag := fs.String("units", "mi", "units for distances, mi or km")
+	format := fs.String("format", "text", "output format: text, csv or geojson")
+	output := fs.String("o", "", "write the itinerary to this file instead of standard output")
+	fs.Parse(args)
+
+	if *startFlag == "" || (*shopsFlag == "") == (*radiusFlag == "") {
+		fmt.Fprintf(fs.Output(), "Usage: quiltshops plan -start LAT,LON (-shops IDS | -radius DISTANCE) [flags]\n\n")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	start, err := proximity.ParsePoint(*startFlag)
+	if err != nil {
+		log.Fatalf("Invalid -start: %v", err)
+	}
+	unit, err := proximity.ParseUnit(*unitsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+
+	requireDatabase(*dbPath, "run quiltshops merge first")
+	db, err := proximity.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	var shops []proximity.Shop
+	if *shopsFlag != "" {
+		var ids []int
+		for _, field := range strings.Split(*shopsFlag, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				log.Fatalf("Invalid shop id %q", field)
+			}
+			ids = append(ids, id)
+		}
+		if shops, err = db.ShopsByID(ctx, ids); err != nil {
+			log.Fatalf("Error loading shops: %v", err)
+		}
+	} else {
+		radius, err := proximity.ParseDistance(*radiusFlag, unit)
+		if err != nil {
+			log.Fatalf("Invalid -radius: %v", err)
+		}
+		page, err := db.WithinRadius(ctx, proximity.RadiusQuery{
+			Center: start,
+			Radius: radius,
+			Filter: proximity.Filter{State: *state, City: *city},
+			Limit:  *maxStops,
+		})
+		if err != nil {
+			log.Fatalf("Error finding shops: %v", err)
+		}
+		for _, m := range page.Matches {
+			shops = append(shops, m.Shop)
+		}
+	}
+
+	if len(shops) == 0 {
+		log.Fatalf("No quilt shops to visit")
+	}
+
+	trip := proximity.PlanTrip(start, shops, proximity.TripOptions{
+		RoundTrip:  *roundTrip,
+		RoadFactor: *roadFactor,
+	})
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if *output != "" {
+		if f, err = os.Create(*output); err != nil {
+			log.Fatalf("Error creating %s: %v", *output, err)
+		}
+		w = f
+	}
+
+	switch *format {
+	case "text":
+		err = writeTripText(w, trip, unit, *unitsFlag)
+	case "csv":
+		err = writeTripCSV(w, trip, unit, *unitsFlag)
+	case "geojson":
+		err = writeTripGeoJSON(w, trip, unit, *unitsFlag)
+	default:
+		log.Fatalf("Unknown -format %q (use text, csv or geojson)", *format)
+	}
+	if err != nil {
+		log.Fatalf("Error writing itinerary: %v", err)
+	}
+	if f != nil {
+		// A full disk may only show up when the last of the file is written
+		if err := f.Close(); err != nil {
+			log.Fatalf("Error writing %s: %v", *output, err)
+		}
+		log.Printf("✓ Wrote %d stops to %s", len(trip.Stops), *output)
+	}
+}
+
+// stopName labels the end of a leg, with nil meaning the start
+func stopName(s *proximity.Shop) string {
+	if s == nil {
+		return "Start"
+	}
+	return fmt.Sprintf("%s (%s, %s)", s.Name, s.City, s.State)
+}
+
+// writeTripText prints the itinerary for reading
+func writeTripText(w io.Writer, trip proximity.Itinerary, unit proximity.Distance, units string) error {
+	fmt.Fprintf(w, "Quilt shop trip from %s: %d stops, %.1f %s\n\n", trip.Start, len(trip.Stops), trip.Total.In(unit), units)
+
+	var sofar proximity.Distance
+	for i, leg := range trip.Legs {
+		sofar += leg.Distance
+		fmt.Fprintf(w, "%3d. %6.1f %s %-2s  %s\n", i+1, leg.Distance.In(unit), units,
+			proximity.CompassPoint(leg.Bearing), stopName(leg.To))
+		if leg.To != nil && leg.To.Address != "" {
+			fmt.Fprintf(w, "                    %s\n", leg.To.Address)
+		}
+		fmt.Fprintf(w, "                    %.1f %s so far\n", sofar.In(unit), units)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// writeTripCSV writes one row per leg for spreadsheets
+func writeTripCSV(w io.Writer, trip proximity.Itinerary, unit proximity.Distance, units string) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"stop", "shop_id", "name", "address", "city", "state", "latitude", "longitude",
+		"leg_" + units, "total_" + units})
+
+	var sofar proximity.Distance
+	for i, leg := range trip.Legs {
+		sofar += leg.Distance
+		row := []string{strconv.Itoa(i + 1), "", "Start", "", "", "",
+			fmtFloat(trip.Start.Latitude), fmtFloat(trip.Start.Longitude)}
+		if s := leg.To; s != nil {
+			row = []string{strconv.Itoa(i + 1), strconv.Itoa(s.ID), s.Name, s.Address, s.City, s.State,
+				fmtFloat(s.Latitude), fmtFloat(s.Longitude)}
+		}
+		row = append(row, fmt.Sprintf("%.2f", leg.Distance.In(unit)), fmt.Sprintf("%.2f", sofar.In(unit)))
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeTripGeoJSON writes the stops as points and the route as a line, for map
+// tools and phone apps
+func writeTripGeoJSON(w io.Writer, trip proximity.Itinerary, unit proximity.Distance, units string) error {
+	type geometry struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string                 `json:"type"`
+		Geometry   geometry               `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+
+	line := [][]float64{{trip.Start.Longitude, trip.Start.Latitude}}
+	features := []feature{{
+		Type:       "Feature",
+		Geometry:   geometry{"Point", []float64{trip.Start.Longitude, trip.Start.Latitude}},
+		Properties: map[string]interface{}{"stop": 0, "name": "Start"},
+	}}
+	for i, leg := range trip.Legs {
+		s := leg.To
+		if s == nil {
+			line = append(line, []float64{trip.Start.Longitude, trip.Start.Latitude})
+			continue
+		}
+		line = append(line, []float64{s.Longitude, s.Latitude})
+		features = append(features, feature{
+			Type:     "Feature",
+			Geometry: geometry{"Point", []float64{s.Longitude, s.Latitude}},
+			Properties: map[string]interface{}{
+				"stop":         i + 1,
+				"shop_id":      s.ID,
+				"name":         s.Name,
+				"address":      s.Address,
+				"city":         s.City,
+				"state":        s.State,
+				"leg_" + units: leg.Distance.In(unit),
+			},
+		})
+	}
+	features = append(features, feature{
+		Type:       "Feature",
+		Geometry:   geometry{"LineString", line},
+		Properties: map[string]interface{}{"name": "Route", "total_" + units: trip.Total.In(unit)},
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"type": "FeatureCollection", "features": features})
+}
+
+// fmtFloat writes a coordinate without needless digits
+func fmtFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
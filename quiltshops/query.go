@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	_ "modernc.org/sqlite"
+)
+
+// openQueryDatabase opens the database a query command reads: the -db
+// path, the -state database or the merged database, in that order
+func openQueryDatabase(cfg *config, state, dbPath string) (*sql.DB, string) {
+	path, hint := dbPath, "check the -db path"
+	switch {
+	case path != "":
+	case state != "":
+		path, hint = stateDatabasePath(state), "run quiltshops scrape -state "+state+" first"
+		for _, src := range cfg.sources {
+			if src.State() == geocode.StateCode(state) {
+				path = cfg.databasePath(src)
+				break
+			}
+		}
+	default:
+		path, hint = cfg.Paths.Merged, "run quiltshops merge first"
+	}
+	requireDatabase(path, hint)
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	return db, path
+}
+
+// columns returns the names of the columns of the quilt_shops table, which
+// differ between the state databases and the merged one
+func columns(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('quilt_shops')")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema: %w", err)
+	}
+	defer rows.Close()
+
+	cols := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to check schema: %w", err)
+		}
+		cols[name] = true
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no quilt_shops table")
+	}
+	return cols, rows.Err()
+}
+
+// printTable writes query results as aligned columns under a header, with
+// NULL as blank
+func printTable(db *sql.DB, query string, args ...interface{}) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query shops: %w", err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(names, "\t"))
+
+	values := make([]sql.NullString, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan shop: %w", err)
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = v.String
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if count == 0 {
+		fmt.Println("No quilt shops found")
+		return nil
+	}
+	return w.Flush()
+}
+
+// runStats counts the shops in a database: by state in the merged
+// database, and by city with geocoding progress in a state database
+func runStats(cfg *config, args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	state := fs.String("state", "", "count this state's scraped shops instead of the merged database, like VA")
+	dbPath := fs.String("db", "", "database to count instead")
+	n := fs.Int("n", 20, "number of cities to list")
+	fs.Parse(args)
+
+	db, path := openQueryDatabase(cfg, *state, *dbPath)
+	defer db.Close()
+	cols, err := columns(db)
+	if err != nil {
+		log.Fatalf("Error reading %s: %v", path, err)
+	}
+
+	// Databases record where their shops came from, and the merged one its build
+	var hasMetadata bool
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'metadata'").Scan(&hasMetadata)
+	if hasMetadata {
+		fmt.Printf("Metadata (%s):\n\n", path)
+		if err := printTable(db, "SELECT key, value FROM metadata ORDER BY key"); err != nil {
+			log.Fatalf("Error reading metadata: %v", err)
+		}
+		fmt.Println()
+	}
+
+	if cols["state"] {
+		fmt.Printf("Quilt shops by state (%s):\n\n", path)
+		if err := printTable(db, "SELECT state, COUNT(*) AS count FROM quilt_shops GROUP BY state ORDER BY state"); err != nil {
+			log.Fatalf("Error counting shops: %v", err)
+		}
+		fmt.Println()
+	}
+
+	if cols["latitude"] {
+		fmt.Printf("Geocoding (%s):\n\n", path)
+		err := printTable(db, `
+			SELECT COUNT(*) AS total,
+				SUM(CASE WHEN latitude IS NOT NULL THEN 1 ELSE 0 END) AS geocoded,
+				SUM(CASE WHEN latitude IS NULL AND geocode_attempted_at IS NOT NULL THEN 1 ELSE 0 END) AS failed
+			FROM quilt_shops
+		`)
+		if err != nil {
+			log.Fatalf("Error counting shops: %v", err)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Quilt shops by city (%s):\n\n", path)
+	err = printTable(db, "SELECT city, COUNT(*) AS count FROM quilt_shops GROUP BY city ORDER BY count DESC, city LIMIT ?", *n)
+	if err != nil {
+		log.Fatalf("Error counting shops: %v", err)
+	}
+}
+
+// runCity lists the shops in a city, ignoring case
+func runCity(cfg *config, args []string) {
+	fs := flag.NewFlagSet("city", flag.ExitOnError)
+	state := fs.String("state", "", "look in this state's scraped shops instead of the merged database, like VA")
+	dbPath := fs.String("db", "", "database to look in instead")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: quiltshops city [flags] CITY\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, path := openQueryDatabase(cfg, *state, *dbPath)
+	defer db.Close()
+	cols, err := columns(db)
+	if err != nil {
+		log.Fatalf("Error reading %s: %v", path, err)
+	}
+
+	// Older state databases have no website column, and only the merged
+	// one has a state
+	fields := []string{"name", "address", "city", "phone", "email"}
+	for _, optional := range []string{"state", "website"} {
+		if cols[optional] {
+			fields = append(fields, optional)
+		}
+	}
+	where := "city = ? COLLATE NOCASE"
+	queryArgs := []interface{}{fs.Arg(0)}
+	if cols["state"] && *state != "" {
+		where += " AND state = ?"
+		queryArgs = append(queryArgs, strings.ToUpper(*state))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM quilt_shops WHERE %s ORDER BY name", strings.Join(fields, ", "), where)
+	if err := printTable(db, query, queryArgs...); err != nil {
+		log.Fatalf("Error finding shops: %v", err)
+	}
+}
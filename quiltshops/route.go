@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/proximity"
+)
+
+// runRoute lists the quilt shops along a route, in the order a driver
+// reaches them
+func runRoute(cfg *config, args []string) {
+	fs := flag.NewFlagSet("route", flag.ExitOnError)
+	dbPath := fs.String("db", cfg.Paths.Merged, "merged quilt shops database")
+	widthFlag := fs.String("width", "5mi", "list shops within this distance of the route, like 5mi or 8km")
+	points := fs.String("points", "", "the route as latitude,longitude pairs separated by semicolons, instead of a file")
+	unitsFlag := fs.String("units", "mi", "units for distances, mi or km")
+	state := fs.String("state", "", "only shops in this state, like VA")
+	city := fs.String("city", "", "only shops in this city")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: quiltshops route [flags] ROUTE_FILE\n\n")
+		fmt.Fprintf(fs.Output(), "ROUTE_FILE is GPX, GeoJSON with a LineString, or one latitude,longitude\n")
+		fmt.Fprintf(fs.Output(), "per line; use - for standard input.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (fs.NArg() == 1) == (*points != "") || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	unit, err := proximity.ParseUnit(*unitsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+	width, err := proximity.ParseDistance(*widthFlag, unit)
+	if err != nil {
+		log.Fatalf("Invalid -width: %v", err)
+	}
+
+	var in io.Reader = strings.NewReader(*points)
+	if fs.NArg() == 1 && fs.Arg(0) == "-" {
+		in = os.Stdin
+	} else if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			log.Fatalf("Error opening route: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	route, err := proximity.ParseRoute(in)
+	if err != nil {
+		log.Fatalf("Error reading route: %v", err)
+	}
+
+	requireDatabase(*dbPath, "run quiltshops merge first")
+	db, err := proximity.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	matches, err := db.AlongRoute(context.Background(), route, width, proximity.Filter{State: *state, City: *city})
+	if err != nil {
+		log.Fatalf("Error finding shops: %v", err)
+	}
+
+	fmt.Printf("%d quilt shops within %s %s of the %.1f %s route\n\n", len(matches),
+		strconv.FormatFloat(width.In(unit), 'f', -1, 64), *unitsFlag,
+		proximity.RouteLength(route).In(unit), *unitsFlag)
+	for i, m := range matches {
+		fmt.Printf("%3d. at %6.1f %s  %5.1f %s %-2s off  %s - %s, %s\n", i+1,
+			m.Along.In(unit), *unitsFlag, m.Distance.In(unit), *unitsFlag,
+			proximity.CompassPoint(m.Bearing), m.Name, m.City, m.State)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// runScrape fetches each chosen source's list into its state database
+func runScrape(cfg *config, args []string) {
+	fs := flag.NewFlagSet("scrape", flag.ExitOnError)
+	sel := addSelectionFlags(fs, cfg)
+	options := map[string]string{}
+	fs.Func("option", "set a parser option of the source for this run, like backend=pdftotext (repeatable, with one source)",
+		func(value string) error {
+			key, v, ok := strings.Cut(value, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return fmt.Errorf("want key=value")
+			}
+			options[strings.TrimSpace(key)] = v
+			return nil
+		})
+	fs.Parse(args)
+
+	sources, err := sel.sources()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(options) > 0 {
+		if len(sources) > 1 {
+			log.Fatal("-option needs a single source, pick one with -state or -source")
+		}
+		if sources[0], err = withOptions(cfg, sources[0], options); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, src := range sources {
+		log.Printf("Fetching quilt shops from %s...", src.Name())
+		shops, err := src.Fetch(ctx)
+		if err != nil {
+			log.Fatalf("Error fetching quilt shops from %s: %v", src.Name(), err)
+		}
+
+		log.Printf("Found %d quilt shops\n", len(shops))
+
+		// Create database and store data
+		path := sel.databasePath(src)
+		log.Println("Creating SQLite database...")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			log.Fatalf("Error creating database directory: %v", err)
+		}
+		// A list kept locally was fetched when it was downloaded
+		_, sc, _ := cfg.source(src.Name())
+		info := source.Info{Source: src.Name(), Type: sc.Type, State: src.State(), URL: sc.URL}
+		if sc.Path != "" {
+			if fi, err := os.Stat(sc.Path); err == nil {
+				info.FetchedAt = fi.ModTime()
+			}
+		}
+		if err := source.CreateDatabase(path, info, shops); err != nil {
+			log.Fatalf("Error creating database: %v", err)
+		}
+
+		log.Printf("Successfully created %s with %d quilt shops\n", path, len(shops))
+	}
+}
+
+// withOptions rebuilds a source with parser options set over its config's
+func withOptions(cfg *config, src source.Source, options map[string]string) (source.Source, error) {
+	_, sc, err := cfg.source(src.Name())
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string]string{}
+	for key, v := range sc.Options {
+		merged[key] = v
+	}
+	for key, v := range options {
+		merged[key] = v
+	}
+	sc.Options = merged
+	return source.New(sc)
+}
@@ -0,0 +1,149 @@
+// Package csvsource reads quilt shop lists published as CSV files with a
+// header row, like a guild's spreadsheet export
+package csvsource
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+func init() {
+	source.Register("csv", New)
+}
+
+// fields are the shop fields a column can hold, and the option that names
+// the column for each
+var fields = []string{"name", "address", "city", "phone", "email", "website"}
+
+// CSV is a quilt shop list in a CSV file
+type CSV struct {
+	config source.Config
+	// columns maps each shop field to the header of its column
+	columns map[string]string
+	comma   rune
+}
+
+// New returns the source a config of type csv declares. The options
+// name_column, address_column, city_column, phone_column, email_column and
+// website_column give the headers of the columns, which default to the
+// field names and are matched ignoring case; delimiter is the field
+// separator, a comma by default.
+func New(c source.Config) (source.Source, error) {
+	known := []string{"delimiter"}
+	for _, field := range fields {
+		known = append(known, field+"_column")
+	}
+	if err := c.CheckOptions(known...); err != nil {
+		return nil, err
+	}
+	if c.URL == "" && c.Path == "" {
+		return nil, fmt.Errorf("source %s needs a url or path", c.Name)
+	}
+
+	delimiter := c.Option("delimiter", ",")
+	comma, size := utf8.DecodeRuneInString(delimiter)
+	if size == 0 || size != len(delimiter) {
+		return nil, fmt.Errorf("source %s: delimiter %q must be one character", c.Name, delimiter)
+	}
+
+	columns := map[string]string{}
+	for _, field := range fields {
+		columns[field] = c.Option(field+"_column", field)
+	}
+	return &CSV{config: c, columns: columns, comma: comma}, nil
+}
+
+// Name implements source.Source
+func (s *CSV) Name() string { return s.config.Name }
+
+// State implements source.Source
+func (s *CSV) State() string { return s.config.State }
+
+// Query implements source.Querier. Spreadsheets often have the street in
+// the address column and the city in its own, so when the address isn't a
+// full address line the query is built from the street and the city.
+func (s *CSV) Query(shop source.Shop) geocode.Query {
+	if q := geocode.ParseAddressLine(shop.Address); q.City != "" {
+		return q
+	}
+	return geocode.Query{
+		Street:  geocode.CleanStreet(shop.Address),
+		City:    shop.City,
+		State:   s.config.State,
+		Country: "USA",
+	}
+}
+
+// Fetch reads the shops from the file
+func (s *CSV) Fetch(ctx context.Context) ([]source.Shop, error) {
+	list, err := source.Open(ctx, s.config)
+	if err != nil {
+		return nil, err
+	}
+	defer list.Close()
+	return s.parseShops(list)
+}
+
+// parseShops reads a shop from each row, skipping rows without a name
+func (s *CSV) parseShops(r io.Reader) ([]source.Shop, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = s.comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	// Find the column of each field; only the name is required
+	index := map[string]int{}
+	for i, h := range header {
+		for _, field := range fields {
+			if strings.EqualFold(strings.TrimSpace(h), s.columns[field]) {
+				index[field] = i
+			}
+		}
+	}
+	if _, ok := index["name"]; !ok {
+		return nil, fmt.Errorf("no %q column in the CSV header", s.columns["name"])
+	}
+
+	var shops []source.Shop
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		get := func(field string) string {
+			i, ok := index[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		shop := source.Shop{
+			Name:    get("name"),
+			Address: get("address"),
+			City:    get("city"),
+			Phone:   get("phone"),
+			Email:   get("email"),
+			Website: get("website"),
+		}
+		if shop.Name != "" {
+			shops = append(shops, shop)
+		}
+	}
+	return shops, nil
+}
@@ -0,0 +1,91 @@
+package csvsource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+func TestFetch(t *testing.T) {
+	list := "Shop;Street;Town;Phone;Notes\n" +
+		"Stitchin' Post;311 W Cascade Ave;Sisters;541-549-6061;open daily\n" +
+		";;;;a row of notes\n" +
+		"Quilt Shop of Bend;1 Wall St, Bend, OR 97701;Bend\n"
+	path := filepath.Join(t.TempDir(), "shops.csv")
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := New(source.Config{
+		Name:  "guild",
+		State: "OR",
+		Path:  path,
+		Options: map[string]string{
+			"name_column":    "shop",
+			"address_column": "street",
+			"city_column":    "town",
+			"delimiter":      ";",
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	shops, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := []source.Shop{
+		{Name: "Stitchin' Post", Address: "311 W Cascade Ave", City: "Sisters", Phone: "541-549-6061"},
+		{Name: "Quilt Shop of Bend", Address: "1 Wall St, Bend, OR 97701", City: "Bend"},
+	}
+	if !reflect.DeepEqual(shops, want) {
+		t.Fatalf("Fetch() = %+v, want %+v", shops, want)
+	}
+
+	q := source.QueryFor(src, shops[0])
+	if q.Street != "311 W Cascade Ave" || q.City != "Sisters" || q.State != "OR" {
+		t.Errorf("QueryFor() = %+v, want the street, town and state", q)
+	}
+	q = source.QueryFor(src, shops[1])
+	if q.Street != "1 Wall St" || q.City != "Bend" || q.PostalCode != "97701" {
+		t.Errorf("QueryFor() = %+v, want the address line split into fields", q)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  source.Config
+		wantErr bool
+	}{
+		{"defaults", source.Config{Name: "guild", State: "OR", URL: "https://example.com/shops.csv"}, false},
+		{"no url or path", source.Config{Name: "guild", State: "OR"}, true},
+		{"long delimiter", source.Config{Name: "guild", State: "OR", Path: "shops.csv", Options: map[string]string{"delimiter": ";;"}}, true},
+		{"unknown option", source.Config{Name: "guild", State: "OR", Path: "shops.csv", Options: map[string]string{"zip_column": "zip"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseShopsNoNameColumn(t *testing.T) {
+	src, err := New(source.Config{Name: "guild", State: "OR", Path: "shops.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.(*CSV).parseShops(strings.NewReader("shop,address\nA,1 Main St\n")); err == nil {
+		t.Error("parseShops() error = nil, want a missing column error")
+	}
+}
@@ -0,0 +1,103 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Local returns the path of the local copy of a source's list, downloading
+// it from the URL first if it isn't there yet. Delete the file, or point
+// the config at a new path, to fetch a fresh copy.
+func Local(ctx context.Context, c Config) (string, error) {
+	if c.Path == "" {
+		return "", fmt.Errorf("source %s has no path", c.Name)
+	}
+	if _, err := os.Stat(c.Path); err == nil {
+		return c.Path, nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to check %s: %w", c.Path, err)
+	}
+	if c.URL == "" {
+		return "", fmt.Errorf("source %s: %s doesn't exist and there's no url to download it from", c.Name, c.Path)
+	}
+
+	log.Printf("Downloading %s to %s...", c.URL, c.Path)
+	if err := download(ctx, c.URL, c.Path); err != nil {
+		return "", err
+	}
+	return c.Path, nil
+}
+
+// Open returns a source's list: the local copy when the config has a path,
+// downloaded first if need be, or else the response from the URL
+func Open(ctx context.Context, c Config) (io.ReadCloser, error) {
+	if c.Path != "" {
+		path, err := Local(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		return f, nil
+	}
+	if c.URL == "" {
+		return nil, fmt.Errorf("source %s has no url or path", c.Name)
+	}
+	return get(ctx, c.URL)
+}
+
+// get requests url and returns the body of a successful response
+func get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// download saves what's at url to path. It writes a temporary file and
+// renames it, so a failed download doesn't leave a partial copy behind to
+// be taken for the real thing next time.
+func download(ctx context.Context, url, path string) error {
+	body, err := get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(out.Name())
+
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
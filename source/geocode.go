@@ -0,0 +1,334 @@
+package source
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+)
+
+// GeocodeShops adds GPS coordinates to the shops in the database at path,
+// asking the geocoder with the source's queries. Shops the geocoder can't
+// find are placed with fallback, if it isn't nil.
+func GeocodeShops(ctx context.Context, path string, src Source, geocoder, fallback geocode.Geocoder) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	addGeocodeColumns(db)
+
+	// Create index for coordinates
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_coordinates ON quilt_shops(latitude, longitude)"); err != nil {
+		log.Printf("Warning: failed to create index: %v", err)
+	}
+
+	// Query shops that need geocoding
+	rows, err := db.Query(`
+		SELECT id, name, COALESCE(address, ''), city
+		FROM quilt_shops
+		WHERE latitude IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query shops: %w", err)
+	}
+	defer rows.Close()
+
+	// Collect shops to geocode
+	type shopToGeocode struct {
+		ID int
+		Shop
+	}
+	var shops []shopToGeocode
+	for rows.Next() {
+		var shop shopToGeocode
+		if err := rows.Scan(&shop.ID, &shop.Name, &shop.Address, &shop.City); err != nil {
+			log.Printf("Warning: failed to scan shop: %v", err)
+			continue
+		}
+		shops = append(shops, shop)
+	}
+
+	if len(shops) == 0 {
+		log.Println("No shops need geocoding. All done!")
+		return nil
+	}
+
+	log.Printf("Geocoding %d shops...\n", len(shops))
+
+	// Geocode each shop
+	for i, shop := range shops {
+		if ctx.Err() != nil {
+			log.Printf("Interrupted with %d shops left", len(shops)-i)
+			return ctx.Err()
+		}
+
+		log.Printf("[%d/%d] %s", i+1, len(shops), shop.Name)
+
+		// Skip if no address
+		if shop.Address == "" {
+			log.Printf("       ⚠ Skipping - no address on file")
+			// Still update the attempted timestamp
+			db.Exec("UPDATE quilt_shops SET geocode_attempted_at = ? WHERE id = ?", time.Now(), shop.ID)
+			continue
+		}
+
+		// Geocode the address, split into fields for structured search
+		query := QueryFor(src, shop.Shop)
+		log.Printf("       %s", query)
+		result, err := geocoder.Geocode(ctx, query)
+		recordRejections(db, shop.ID, query, result.Rejected)
+
+		if ctx.Err() != nil {
+			continue
+		}
+		if geocode.IsTransient(err) {
+			// Don't record the attempt so the shop isn't counted as a miss
+			log.Printf("       ✗ Failed, will retry on the next run: %v", err)
+			continue
+		}
+		if errors.Is(err, geocode.ErrNoResults) && fallback != nil {
+			// Better an approximate pin than dropping the shop from the app
+			if fallbackResult, fallbackErr := fallback.Geocode(ctx, query); fallbackErr == nil {
+				log.Printf("       ⚠ Not found, using the %s centroid", fallbackResult.DisplayName)
+				result, err = fallbackResult, nil
+			}
+		}
+		if err != nil {
+			log.Printf("       ✗ Failed: %v", err)
+			// Update attempted timestamp
+			db.Exec("UPDATE quilt_shops SET geocode_attempted_at = ? WHERE id = ?", time.Now(), shop.ID)
+			continue
+		}
+
+		// Update database with coordinates and match details
+		_, err = db.Exec(`
+			UPDATE quilt_shops
+			SET latitude = ?, longitude = ?, geocode_attempted_at = ?,
+				geocode_provider = ?, geocode_match_type = ?, geocode_confidence = ?,
+				geocode_display_name = ?, geocode_class = ?, geocode_type = ?,
+				geocode_county = ?, geocode_postcode = ?, geocode_warning = ?
+			WHERE id = ?
+		`, result.Coords.Latitude, result.Coords.Longitude, time.Now(),
+			result.Provider, string(result.MatchType), result.Confidence,
+			result.DisplayName, result.Class, result.Type,
+			result.Address.County, result.Address.Postcode, result.Warning, shop.ID)
+
+		if err != nil {
+			log.Printf("       ✗ Failed to update database: %v", err)
+		} else {
+			source := result.Provider
+			if result.Cached {
+				source += ", cached"
+			}
+			log.Printf("       ✓ %.4f, %.4f (%s)", result.Coords.Latitude, result.Coords.Longitude, source)
+			if result.IsApproximate() {
+				log.Printf("       ⚠ Approximate - %s match: %s", result.MatchType, result.DisplayName)
+			}
+			if result.Warning != "" {
+				log.Printf("       ⚠ Check this one - %s", result.Warning)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GeocodeShopsBatch adds GPS coordinates to the shops in the database at
+// path with one Census batch upload, then writes every result back in one
+// transaction. Matches outside the source's state count as misses.
+func GeocodeShopsBatch(ctx context.Context, path string, src Source, census *geocode.Census) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	addGeocodeColumns(db)
+
+	rows, err := db.Query(`
+		SELECT id, address, city
+		FROM quilt_shops
+		WHERE latitude IS NULL AND address IS NOT NULL AND address != ''
+		ORDER BY id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query shops: %w", err)
+	}
+
+	var addresses []geocode.BatchAddress
+	for rows.Next() {
+		var id int
+		var shop Shop
+		if err := rows.Scan(&id, &shop.Address, &shop.City); err != nil {
+			log.Printf("Warning: failed to scan shop: %v", err)
+			continue
+		}
+		query := QueryFor(src, shop)
+		if query.Street == "" {
+			// The batch service needs a street; leave these for the geocode command
+			log.Printf("⚠ Skipping shop %d - can't split address: %s", id, shop.Address)
+			continue
+		}
+		addresses = append(addresses, geocode.BatchAddress{
+			ID:     strconv.Itoa(id),
+			Street: query.Street,
+			City:   query.City,
+			State:  query.State,
+			Zip:    query.PostalCode,
+		})
+	}
+	rows.Close()
+
+	if len(addresses) == 0 {
+		log.Println("No shops need geocoding. All done!")
+		return nil
+	}
+
+	log.Printf("Uploading %d addresses to the Census batch geocoder...\n", len(addresses))
+	results, err := census.GeocodeBatch(ctx, addresses)
+	if err != nil {
+		return fmt.Errorf("failed to batch geocode: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	bounds, haveBounds := geocode.StateBounds(src.State())
+	var matched, missed int
+	for _, result := range results {
+		if result.Status == geocode.BatchMatch && result.Coords != nil && haveBounds && !bounds.Contains(*result.Coords) {
+			// Treat a match outside the state as a miss and keep it for review
+			shopID, _ := strconv.Atoi(result.ID)
+			_, err := tx.Exec(`
+				INSERT INTO geocode_rejections (shop_id, query, provider, latitude, longitude, display_name, reason)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`, shopID, result.InputAddress, "census", result.Coords.Latitude, result.Coords.Longitude,
+				result.MatchedAddress, "outside "+src.State())
+			if err != nil {
+				return fmt.Errorf("failed to record rejected match for shop %s: %w", result.ID, err)
+			}
+			result.Status = geocode.BatchNoMatch
+		}
+		if result.Status != geocode.BatchMatch || result.Coords == nil {
+			log.Printf("✗ %s: %s", result.InputAddress, result.Status)
+			if _, err := tx.Exec("UPDATE quilt_shops SET geocode_attempted_at = ? WHERE id = ?", now, result.ID); err != nil {
+				return fmt.Errorf("failed to update shop %s: %w", result.ID, err)
+			}
+			missed++
+			continue
+		}
+
+		_, err := tx.Exec(`
+			UPDATE quilt_shops
+			SET latitude = ?, longitude = ?, geocode_attempted_at = ?,
+				geocode_provider = ?, geocode_match_type = ?, geocode_display_name = ?,
+				geocode_tiger_line_id = ?
+			WHERE id = ?
+		`, result.Coords.Latitude, result.Coords.Longitude, now,
+			"census", string(geocode.MatchInterpolated), result.MatchedAddress,
+			result.TigerLineID, result.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update shop %s: %w", result.ID, err)
+		}
+		log.Printf("✓ %s: %.4f, %.4f", result.MatchedAddress, result.Coords.Latitude, result.Coords.Longitude)
+		matched++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Census matched %d of %d addresses (%d not found)", matched, len(addresses), missed)
+	return nil
+}
+
+// ValidateShops reverse geocodes each shop's coordinates and reports shops
+// whose coordinates land outside the city or the source's state
+func ValidateShops(ctx context.Context, path string, src Source, reverse geocode.ReverseGeocoder) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT id, name, city, latitude, longitude
+		FROM quilt_shops
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query shops: %w", err)
+	}
+	defer rows.Close()
+
+	type shopToValidate struct {
+		ID        int
+		Name      string
+		City      string
+		Latitude  float64
+		Longitude float64
+	}
+	var shops []shopToValidate
+	for rows.Next() {
+		var shop shopToValidate
+		if err := rows.Scan(&shop.ID, &shop.Name, &shop.City, &shop.Latitude, &shop.Longitude); err != nil {
+			log.Printf("Warning: failed to scan shop: %v", err)
+			continue
+		}
+		shops = append(shops, shop)
+	}
+
+	if len(shops) == 0 {
+		log.Println("No geocoded shops to check. Run the geocode command first.")
+		return nil
+	}
+
+	state := src.State()
+	var ok, wrongCity, wrongState, failed int
+	for i, shop := range shops {
+		if ctx.Err() != nil {
+			log.Printf("Interrupted with %d shops left", len(shops)-i)
+			break
+		}
+
+		result, err := reverse.ReverseGeocode(ctx, shop.Latitude, shop.Longitude)
+		if ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			log.Printf("✗ [%d] %s: %v", shop.ID, shop.Name, err)
+			failed++
+			continue
+		}
+
+		found := strings.TrimSpace(result.Address.City + ", " + result.Address.State)
+		switch {
+		case !result.Address.InState(state):
+			log.Printf("✗ [%d] %s: listed in %s, %s but coordinates are in %s", shop.ID, shop.Name, shop.City, state, found)
+			wrongState++
+		case !result.Address.InCity(shop.City):
+			log.Printf("⚠ [%d] %s: listed in %s but coordinates are in %s", shop.ID, shop.Name, shop.City, found)
+			wrongCity++
+		default:
+			ok++
+		}
+	}
+
+	log.Printf("Checked %d shops: %d match, %d in another city, %d in another state, %d failed",
+		ok+wrongCity+wrongState+failed, ok, wrongCity, wrongState, failed)
+	return nil
+}
@@ -0,0 +1,225 @@
+// Package htmlsource scrapes quilt shop lists published as web pages with a
+// heading for each city and the shops in blocks under it, like Rona the
+// Ribbiter's state lists
+package htmlsource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+func init() {
+	source.Register("html", New)
+}
+
+// HTML is a quilt shop list on a web page
+type HTML struct {
+	config source.Config
+	// selectors for the city headings, the blocks of shops under them and
+	// the shop names in the blocks
+	city, entry, name string
+}
+
+// New returns the source a config of type html declares. Its options are
+// the CSS selectors for the parts of the page: city_selector (h3),
+// entry_selector (pre.wp-block-verse) and name_selector (strong).
+func New(c source.Config) (source.Source, error) {
+	if err := c.CheckOptions("city_selector", "entry_selector", "name_selector"); err != nil {
+		return nil, err
+	}
+	if c.URL == "" && c.Path == "" {
+		return nil, fmt.Errorf("source %s needs a url or path", c.Name)
+	}
+	return &HTML{
+		config: c,
+		city:   c.Option("city_selector", "h3"),
+		entry:  c.Option("entry_selector", "pre.wp-block-verse"),
+		name:   c.Option("name_selector", "strong"),
+	}, nil
+}
+
+// Name implements source.Source
+func (h *HTML) Name() string { return h.config.Name }
+
+// State implements source.Source
+func (h *HTML) State() string { return h.config.State }
+
+// Fetch scrapes the quilt shops from the page
+func (h *HTML) Fetch(ctx context.Context) ([]source.Shop, error) {
+	page, err := source.Open(ctx, h.config)
+	if err != nil {
+		return nil, err
+	}
+	defer page.Close()
+
+	doc, err := goquery.NewDocumentFromReader(page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return h.parseShops(doc), nil
+}
+
+// Skip list - common non-shop strings to ignore, along with the name of
+// the state
+var skipStrings = map[string]bool{
+	"click here":                            true,
+	"related posts":                         true,
+	"quilt shop lists":                      true,
+	"list of quilt shows":                   true,
+	"quilt shops":                           true,
+	"find a quilt shop":                     true,
+	"big quilter's bucket list":             true,
+	"planning your next quilting adventure": true,
+	"travel tips for your next road trip":   true,
+	"create a realistic road trip budget":   true,
+	"traveling quilters group":              true,
+	"facebook":                              true,
+	"more on the blog":                      true,
+	"from the e-store":                      true,
+	"quilt shop lists in the us":            true,
+}
+
+// parseShops finds the shops on the page
+func (h *HTML) parseShops(doc *goquery.Document) []source.Shop {
+	var shops []source.Shop
+
+	stateName := geocode.StateName(h.config.State)
+
+	// seenShops tracks shops we've already added to prevent duplicates
+	seenShops := make(map[string]bool)
+
+	// Track cities - find all city headers and process shops after each one
+	doc.Find(h.city).Each(func(i int, heading *goquery.Selection) {
+		cityText := strings.TrimSpace(strings.ToLower(heading.Text()))
+
+		// Get following siblings until we hit the next city header
+		// Multiple divs may contain shops for the same city
+		heading.NextAll().EachWithBreak(func(j int, sibling *goquery.Selection) bool {
+			// Check if THIS element is the next city header
+			if sibling.Is(h.city) {
+				return false // Stop iteration
+			}
+
+			// Check if this element contains a city header (next city section)
+			if sibling.Find(h.city).Length() > 0 {
+				return false // Stop iteration
+			}
+
+			// Process all shop blocks within this sibling
+			sibling.Find(h.entry).Each(func(k int, pre *goquery.Selection) {
+				pre.Find(h.name).Each(func(l int, strong *goquery.Selection) {
+					shopName := strings.TrimSpace(strong.Text())
+
+					if shopName == "" || skipStrings[strings.ToLower(shopName)] || strings.EqualFold(shopName, stateName) {
+						return
+					}
+
+					shop := parseShopFromPre(pre.Text(), shopName, cityText)
+					shopKey := strings.ToLower(shop.Name) + "|" + strings.ToLower(shop.City)
+
+					if shop.Name != "" && (shop.Address != "" || shop.Phone != "") && !seenShops[shopKey] {
+						seenShops[shopKey] = true
+						shops = append(shops, shop)
+					}
+				})
+			})
+
+			// Continue to next sibling
+			return true
+		})
+	})
+
+	return shops
+}
+
+// parseShopFromPre parses a shop entry from a pre block's text content
+func parseShopFromPre(preText, shopName, city string) source.Shop {
+	shop := source.Shop{
+		Name: shopName,
+		City: city,
+	}
+
+	// Split the text into lines
+	lines := strings.Split(preText, "\n")
+
+	// Find the line with the shop name, then get the next few lines
+	foundShop := false
+	linesAfterShop := 0
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		// Skip empty lines
+		if line == "" {
+			continue
+		}
+
+		// Found the shop name
+		if strings.Contains(line, shopName) {
+			foundShop = true
+			continue
+		}
+
+		if !foundShop {
+			continue
+		}
+
+		linesAfterShop++
+
+		// Stop after processing 4 lines after the shop name (increased from 3)
+		// Format is typically: address, phone, email, [optional website/notes]
+		if linesAfterShop > 4 {
+			break
+		}
+
+		// Classify this line - order matters!
+		// Check email first (most specific pattern)
+		if isEmail(line) {
+			shop.Email = line
+		} else if isPhone(line) {
+			shop.Phone = line
+		} else if shop.Address == "" {
+			// First non-email, non-phone line is the address
+			shop.Address = line
+		}
+		// Ignore subsequent lines that don't match known patterns
+	}
+
+	return shop
+}
+
+// isEmail checks if a string looks like an email address
+func isEmail(s string) bool {
+	return strings.Contains(s, "@") && strings.Contains(s, ".")
+}
+
+// isPhone checks if a string looks like a phone number
+func isPhone(s string) bool {
+	// Remove common phone number characters
+	cleaned := strings.ReplaceAll(s, "-", "")
+	cleaned = strings.ReplaceAll(cleaned, "(", "")
+	cleaned = strings.ReplaceAll(cleaned, ")", "")
+	cleaned = strings.ReplaceAll(cleaned, " ", "")
+	cleaned = strings.ReplaceAll(cleaned, ".", "")
+	cleaned = strings.ReplaceAll(cleaned, "+", "") // International prefix
+
+	// Phone number must be ONLY digits after cleaning
+	// Valid lengths: 10 (US), 11 (with country code)
+	if len(cleaned) < 10 || len(cleaned) > 11 {
+		return false
+	}
+
+	// Every character must be a digit
+	for _, c := range cleaned {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
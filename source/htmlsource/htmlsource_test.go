@@ -1,6 +1,14 @@
-package main
+package htmlsource
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
 
 func TestIsPhone(t *testing.T) {
 	tests := []struct {
@@ -40,13 +48,13 @@ func TestIsPhone(t *testing.T) {
 
 func TestParseShopFromPre(t *testing.T) {
 	tests := []struct {
-		name         string
-		preText      string
-		shopName     string
-		city         string
-		wantAddress  string
-		wantPhone    string
-		wantEmail    string
+		name        string
+		preText     string
+		shopName    string
+		city        string
+		wantAddress string
+		wantPhone   string
+		wantEmail   string
 	}{
 		{
 			name: "Complete shop with all fields",
@@ -89,3 +97,52 @@ info@melssewing.com`,
 		})
 	}
 }
+
+func TestFetch(t *testing.T) {
+	page := `<html><body>
+<h2>Oregon</h2>
+<div><p><b>Oregon</b></p></div>
+<h2>Sisters</h2>
+<div><p><b>Stitchin' Post</b>
+311 W Cascade Ave, Sisters, OR 97759
+541-549-6061</p></div>
+<div><p><b>Click Here</b></p></div>
+<h2>Bend</h2>
+<div><p><b>Quilt Shop of Bend</b>
+1 Wall St, Bend, OR 97701
+bend@example.com</p></div>
+</body></html>`
+	path := filepath.Join(t.TempDir(), "shops.html")
+	if err := os.WriteFile(path, []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := New(source.Config{
+		Name:    "test",
+		State:   "OR",
+		Path:    path,
+		Options: map[string]string{"city_selector": "h2", "entry_selector": "p", "name_selector": "b"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	shops, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := []source.Shop{
+		{Name: "Stitchin' Post", Address: "311 W Cascade Ave, Sisters, OR 97759", City: "sisters", Phone: "541-549-6061"},
+		{Name: "Quilt Shop of Bend", Address: "1 Wall St, Bend, OR 97701", City: "bend", Email: "bend@example.com"},
+	}
+	if !reflect.DeepEqual(shops, want) {
+		t.Errorf("Fetch() = %+v, want %+v", shops, want)
+	}
+}
+
+func TestNewUnknownOption(t *testing.T) {
+	_, err := New(source.Config{Name: "test", Type: "html", State: "OR", URL: "https://example.com", Options: map[string]string{"city": "h2"}})
+	if err == nil {
+		t.Error("New() error = nil, want an unknown option error")
+	}
+}
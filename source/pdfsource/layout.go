@@ -0,0 +1,282 @@
+package pdfsource
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// lineStyle is how a line of the PDF looks
+type lineStyle struct {
+	Size float64
+	Bold bool
+}
+
+// style returns the line's style
+func (l textLine) style() lineStyle {
+	return lineStyle{Size: l.Size, Bold: l.Bold}
+}
+
+// outranks reports whether s stands out more than other: a larger font, or
+// the same size in bold
+func (s lineStyle) outranks(other lineStyle) bool {
+	if math.Abs(s.Size-other.Size) > 0.5 {
+		return s.Size > other.Size
+	}
+	return s.Bold && !other.Bold
+}
+
+// Roles of lines in the VCQ list
+const (
+	roleBody = iota
+	roleShopName
+	roleCity
+	roleTitle
+)
+
+// layoutRoles maps the styles of the VCQ list to the part they mark out
+type layoutRoles struct {
+	// body is the style most of the text is in
+	body lineStyle
+	// headings gives the role of each style standing out from the body
+	headings map[lineStyle]int
+}
+
+// findLayoutRoles works out which styles are the body text, shop names and
+// city headings by how much text is in each and how much they stand out.
+// Shop names are the next level up from the body and city headings the
+// level above that; anything standing out more, like the title, is a title.
+// It fails when the PDF doesn't use at least two heading levels.
+func findLayoutRoles(lines []textLine) (layoutRoles, error) {
+	chars := map[lineStyle]int{}
+	for _, line := range lines {
+		chars[line.style()] += len(line.Text)
+	}
+
+	roles := layoutRoles{headings: map[lineStyle]int{}}
+	for style, n := range chars {
+		if n > chars[roles.body] || (n == chars[roles.body] && roles.body.outranks(style)) {
+			roles.body = style
+		}
+	}
+
+	var headings []lineStyle
+	for style := range chars {
+		if style.outranks(roles.body) {
+			headings = append(headings, style)
+		}
+	}
+	sort.Slice(headings, func(i, j int) bool {
+		if headings[i].Size != headings[j].Size {
+			return headings[i].Size < headings[j].Size
+		}
+		return !headings[i].Bold && headings[j].Bold
+	})
+
+	// Styles that don't stand out from the level below, like a heading
+	// drawn at 14 and 14.5 points, share its level
+	role := roleShopName
+	for i, style := range headings {
+		if i > 0 && style.outranks(headings[i-1]) {
+			role = min(role+1, roleTitle)
+		}
+		roles.headings[style] = role
+	}
+
+	if role < roleCity {
+		return roles, fmt.Errorf("found %d heading styles above the %.1fpt body text, need separate city and shop name styles",
+			len(headings), roles.body.Size)
+	}
+	return roles, nil
+}
+
+// role returns the part of the list a style marks out
+func (r layoutRoles) role(style lineStyle) int {
+	if role, ok := r.headings[style]; ok {
+		return role
+	}
+	return roleBody
+}
+
+// repeatedLines finds page headers and footers: text that appears on more
+// than one page at the same height
+func repeatedLines(lines []textLine) map[string]bool {
+	pages := map[string]map[int]bool{}
+	for _, line := range lines {
+		key := line.placement()
+		if pages[key] == nil {
+			pages[key] = map[int]bool{}
+		}
+		pages[key][line.Page] = true
+	}
+
+	repeated := map[string]bool{}
+	for key, on := range pages {
+		if len(on) > 1 {
+			repeated[key] = true
+		}
+	}
+	return repeated
+}
+
+// placement identifies a line by its text and height on the page
+func (l textLine) placement() string {
+	return fmt.Sprintf("%.0f|%s", l.Y, l.Text)
+}
+
+// pageContent drops the page headers and footers from lines and works out
+// the roles of the styles in what's left
+func pageContent(lines []textLine) ([]textLine, layoutRoles, error) {
+	repeated := repeatedLines(lines)
+	var content []textLine
+	for _, line := range lines {
+		if !repeated[line.placement()] {
+			content = append(content, line)
+		}
+	}
+	roles, err := findLayoutRoles(content)
+	return content, roles, err
+}
+
+// parseShopsFromLayout parses shop entries from the styled lines of the
+// VCQ PDF. City headings and shop names are told apart from the rest by
+// their font size and weight rather than by what the words look like, so
+// a bold owner's name or an unusual city doesn't throw it off.
+func (p *parser) parseShopsFromLayout(lines []textLine) ([]source.Shop, error) {
+	content, roles, err := pageContent(lines)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseEntries(content, roles), nil
+}
+
+// parseShopsFromText parses shop entries from the plain text of another
+// backend, like pdftotext. Plain text has no fonts, so a line is taken for
+// a city heading or shop name when the styled lines from the built in
+// reader have it in that style, and dropped when they have it repeating
+// on every page. The skip lines are dropped too.
+func (p *parser) parseShopsFromText(text string, styled []textLine) ([]source.Shop, error) {
+	content, roles, err := pageContent(styled)
+	if err != nil {
+		return nil, err
+	}
+	headings := map[string]lineStyle{}
+	for _, line := range content {
+		if roles.role(line.style()) != roleBody {
+			headings[line.Text] = line.style()
+		}
+	}
+	repeated := repeatedLines(styled)
+	furniture := map[string]bool{}
+	for _, line := range styled {
+		if repeated[line.placement()] {
+			furniture[line.Text] = true
+		}
+	}
+
+	var lines []textLine
+	for _, line := range strings.Split(text, "\n") {
+		// Runs of spaces, like pdftotext's for a tab stop, count as one
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || p.skip[line] || furniture[line] {
+			continue
+		}
+		style, ok := headings[line]
+		if !ok {
+			style = roles.body
+		}
+		lines = append(lines, textLine{Text: line, Size: style.Size, Bold: style.Bold})
+	}
+	return p.parseEntries(lines, roles), nil
+}
+
+// parseEntries reads the shops from lines in the styles roles describes.
+// Headings that wrap onto a second line are joined. Entries without a
+// city, state and ZIP line are logged, and kept when they have contact
+// details.
+func (p *parser) parseEntries(lines []textLine, roles layoutRoles) []source.Shop {
+	var shops []source.Shop
+	var currentCity string
+	var current *source.Shop
+	var addressLines []string
+	addressDone := false
+	finish := func() {
+		switch {
+		case current == nil:
+		case addressDone:
+			shops = append(shops, *current)
+		case current.Phone != "" || current.Email != "" || current.Website != "":
+			// A shop whose city, state and ZIP line is missing or garbled
+			// still has contact details, so keep what was parsed
+			current.Address = strings.Join(addressLines, ", ")
+			log.Printf("⚠ %s in %s has no city, state and ZIP line, keeping it with address %q", current.Name, current.City, current.Address)
+			shops = append(shops, *current)
+		default:
+			// Bold text that isn't a shop, like "Hours" in a description,
+			// has neither an address nor contact details
+			log.Printf("⚠ Skipped %q under %s: no address or contact details", current.Name, current.City)
+		}
+		current, addressLines, addressDone = nil, nil, false
+	}
+
+	previousRole := -1
+	for _, line := range lines {
+		role := roles.role(line.style())
+		switch role {
+		case roleTitle:
+			// Titles and section banners
+
+		case roleCity:
+			if previousRole == roleCity {
+				currentCity += " " + line.Text
+				break
+			}
+			finish()
+			currentCity = line.Text
+
+		case roleShopName:
+			if previousRole == roleShopName && current != nil {
+				current.Name += " " + line.Text
+				break
+			}
+			finish()
+			if currentCity != "" {
+				current = &source.Shop{Name: line.Text, City: currentCity}
+			}
+
+		default:
+			if current == nil {
+				break
+			}
+			switch {
+			case p.cityStateZip.MatchString(line.Text):
+				if !addressDone {
+					current.Address = strings.Join(addressLines, ", ")
+					addressDone = true
+				}
+			case phoneRegex.MatchString(line.Text):
+				if current.Phone == "" {
+					current.Phone = line.Text
+				}
+			case emailRegex.MatchString(line.Text):
+				if current.Email == "" {
+					current.Email = line.Text
+				}
+			case websiteRegex.MatchString(line.Text):
+				if current.Website == "" {
+					current.Website = line.Text
+				}
+			case !addressDone:
+				addressLines = append(addressLines, line.Text)
+			}
+		}
+		previousRole = role
+	}
+	finish()
+
+	return shops
+}
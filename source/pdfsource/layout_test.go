@@ -0,0 +1,194 @@
+package pdfsource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// testParser parses the made up lists in these tests
+var testParser = newParser("VA", []string{"Quilt Shops", "2025-V1.0"})
+
+// vcqPages is a made up two page VCQ list. The page header and footer
+// repeat, cities are 14pt bold, shop names 11pt bold and the rest 10pt.
+var vcqPages = [][]pdfLine{
+	{
+		{72, 760, 8, "Virginia Consortium of Quilters", false},
+		{72, 730, 20, "Quilt Shops", true},
+		{72, 700, 14, "Charlottesville", true},
+		{72, 684, 11, "Les Fabriques", true},
+		{72, 671, 10, "1135 Sunset Ave", false},
+		{72, 658, 10, "Charlottesville, VA 22903", false},
+		{72, 645, 10, "(434) 555-0100", false},
+		{72, 632, 10, "info@lesfabriques.example", false},
+		{72, 619, 10, "www.lesfabriques.example", false},
+		// Words the old parser mistook for cities
+		{72, 606, 10, "Emily Isaman", false},
+		{72, 593, 10, "Owner", false},
+		{72, 580, 11, "Hours", true},
+		{72, 567, 10, "Closed Sunday", false},
+		{72, 540, 14, "Virginia", true},
+		{72, 524, 14, "Beach", true},
+		{72, 508, 11, "Quilting Adventures &", true},
+		{72, 495, 11, "Sewing Studio", true},
+		{72, 482, 10, "Shopping Center", false},
+		{72, 469, 10, "2304 Laskin Rd, Suite 5", false},
+		{72, 456, 10, "Virginia Beach, VA 23454", false},
+		{72, 443, 10, "757-555-0199", false},
+		{72, 30, 8, "2025-V1.0", false},
+	},
+	{
+		{72, 760, 8, "Virginia Consortium of Quilters", false},
+		{72, 700, 14, "Mount Crawford", true},
+		{72, 684, 11, "Sew Classic", true},
+		{72, 671, 10, "121 Carpenter Lane", false},
+		{72, 658, 10, "Mount Crawford, VA 22841", false},
+		{72, 645, 10, "http://sewclassic.example", false},
+		// The city, state and ZIP line is missing
+		{72, 619, 11, "Bits & Pieces", true},
+		{72, 606, 10, "45 Main St", false},
+		{72, 593, 10, "(540) 555-0123", false},
+		{72, 30, 8, "2025-V1.0", false},
+	},
+}
+
+// vcqShops are the shops in vcqPages
+var vcqShops = []source.Shop{
+	{
+		Name: "Les Fabriques", Address: "1135 Sunset Ave", City: "Charlottesville",
+		Phone: "(434) 555-0100", Email: "info@lesfabriques.example", Website: "www.lesfabriques.example",
+	},
+	{
+		Name: "Quilting Adventures & Sewing Studio", Address: "Shopping Center, 2304 Laskin Rd, Suite 5",
+		City: "Virginia Beach", Phone: "757-555-0199",
+	},
+	{
+		Name: "Sew Classic", Address: "121 Carpenter Lane", City: "Mount Crawford",
+		Website: "http://sewclassic.example",
+	},
+	{Name: "Bits & Pieces", Address: "45 Main St", City: "Mount Crawford", Phone: "(540) 555-0123"},
+}
+
+func TestParseShopsFromLayout(t *testing.T) {
+	lines, err := readPDFLayout(writeTestPDF(t, vcqPages))
+	if err != nil {
+		t.Fatalf("readPDFLayout() error = %v", err)
+	}
+
+	shops, err := testParser.parseShopsFromLayout(lines)
+	if err != nil {
+		t.Fatalf("parseShopsFromLayout() error = %v", err)
+	}
+	want := vcqShops
+	if len(shops) != len(want) {
+		t.Fatalf("parseShopsFromLayout() found %d shops, want %d: %+v", len(shops), len(want), shops)
+	}
+	for i := range want {
+		if shops[i] != want[i] {
+			t.Errorf("shop %d = %+v, want %+v", i, shops[i], want[i])
+		}
+	}
+}
+
+func TestParseShopsFromText(t *testing.T) {
+	lines, err := readPDFLayout(writeTestPDF(t, vcqPages))
+	if err != nil {
+		t.Fatalf("readPDFLayout() error = %v", err)
+	}
+	// pdftotext's text of the same pages, spacing things out a little
+	// differently
+	var text strings.Builder
+	for i, page := range vcqPages {
+		for _, line := range page {
+			text.WriteString(strings.ReplaceAll(line.Text, ", VA ", ", VA   ") + "\n")
+		}
+		if i < len(vcqPages)-1 {
+			text.WriteString("\f\n")
+		}
+	}
+
+	shops, err := testParser.parseShopsFromText(text.String(), lines)
+	if err != nil {
+		t.Fatalf("parseShopsFromText() error = %v", err)
+	}
+	if len(shops) != len(vcqShops) {
+		t.Fatalf("parseShopsFromText() found %d shops, want %d: %+v", len(shops), len(vcqShops), shops)
+	}
+	for i := range vcqShops {
+		if shops[i] != vcqShops[i] {
+			t.Errorf("shop %d = %+v, want %+v", i, shops[i], vcqShops[i])
+		}
+	}
+}
+
+func TestReadPDFLayoutStyles(t *testing.T) {
+	lines, err := readPDFLayout(writeTestPDF(t, vcqPages[1:]))
+	if err != nil {
+		t.Fatalf("readPDFLayout() error = %v", err)
+	}
+	if len(lines) != len(vcqPages[1]) {
+		t.Fatalf("readPDFLayout() returned %d lines, want %d", len(lines), len(vcqPages[1]))
+	}
+	for i, line := range lines {
+		want := vcqPages[1][i]
+		if line.Text != want.Text || line.Size != want.Size || line.Bold != want.Bold || line.Page != 1 || line.Y != want.Y {
+			t.Errorf("line %d = %+v, want %+v", i, line, want)
+		}
+	}
+}
+
+func TestFindLayoutRoles(t *testing.T) {
+	line := func(text string, size float64, bold bool) textLine {
+		return textLine{Text: text, Size: size, Bold: bold}
+	}
+	body := strings.Repeat("body text ", 20)
+
+	tests := []struct {
+		name    string
+		lines   []textLine
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:  "size levels",
+			lines: []textLine{line("Title", 20, true), line("City", 14, true), line("Shop", 11, true), line(body, 10, false)},
+			want:  map[string]int{"Title": roleTitle, "City": roleCity, "Shop": roleShopName, body: roleBody},
+		},
+		{
+			name:  "bold body size shop names",
+			lines: []textLine{line("City", 12, false), line("Shop", 10, true), line(body, 10, false)},
+			want:  map[string]int{"City": roleCity, "Shop": roleShopName, body: roleBody},
+		},
+		{
+			name:  "half point differences share a level",
+			lines: []textLine{line("City", 14, true), line("Other City", 14.5, true), line("Shop", 11, true), line(body, 10, false)},
+			want:  map[string]int{"City": roleCity, "Other City": roleCity, "Shop": roleShopName},
+		},
+		{
+			name:    "one heading style",
+			lines:   []textLine{line("City", 14, true), line(body, 10, false)},
+			wantErr: true,
+		},
+		{
+			name:    "no headings",
+			lines:   []textLine{line(body, 10, false)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := findLayoutRoles(tt.lines)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findLayoutRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, l := range tt.lines {
+				if want, ok := tt.want[l.Text]; ok {
+					if got := roles.role(l.style()); got != want {
+						t.Errorf("role(%q) = %d, want %d", l.Text, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package pdfsource parses quilt shop lists published as PDFs with a heading
+// for each city and the shops under it, like the Virginia Consortium of
+// Quilters' list
+package pdfsource
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+// Regular expressions for the lines of a shop entry
+var (
+	phoneRegex   = regexp.MustCompile(`^\(?\d{3}\)?[-.\s]?\d{3}[-.\s]?\d{4}`)
+	emailRegex   = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	websiteRegex = regexp.MustCompile(`^(?:www\.|https?://)`)
+)
+
+func init() {
+	source.Register("pdf", New)
+}
+
+// PDF is a quilt shop list published as a PDF
+type PDF struct {
+	config source.Config
+	// backend is how to pull text out of the PDF
+	backend string
+	parser  *parser
+}
+
+// parser holds what the shop entries of one list look like
+type parser struct {
+	// cityStateZip matches the last line of an address
+	cityStateZip *regexp.Regexp
+	// skip are lines of page furniture for the pdftotext parser to ignore
+	skip map[string]bool
+}
+
+// newParser returns a parser for a list of shops in state, ignoring the
+// skip lines
+func newParser(state string, skip []string) *parser {
+	p := &parser{
+		cityStateZip: regexp.MustCompile(`^(.+),\s*` + regexp.QuoteMeta(state) + `\s+\d{5,6}`),
+		skip:         map[string]bool{},
+	}
+	for _, line := range skip {
+		if line = strings.TrimSpace(line); line != "" {
+			p.skip[line] = true
+		}
+	}
+	return p
+}
+
+// New returns the source a config of type pdf declares. The PDF is kept at
+// the config's path, downloaded from the URL if it isn't there. Its options
+// are backend, how to pull text out of the PDF (go, built in, or pdftotext,
+// which needs poppler installed), and skip_lines, headers and footers for
+// the pdftotext parser to ignore besides those repeating on every page,
+// separated by |.
+func New(c source.Config) (source.Source, error) {
+	if err := c.CheckOptions("backend", "skip_lines"); err != nil {
+		return nil, err
+	}
+	if c.Path == "" {
+		return nil, fmt.Errorf("source %s needs a path to keep the PDF at", c.Name)
+	}
+	backend := c.Option("backend", backendGo)
+	if backend != backendGo && backend != backendPdftotext {
+		return nil, fmt.Errorf("source %s: unknown PDF backend %q (use %s or %s)", c.Name, backend, backendGo, backendPdftotext)
+	}
+	return &PDF{
+		config:  c,
+		backend: backend,
+		parser:  newParser(c.State, strings.Split(c.Option("skip_lines", ""), "|")),
+	}, nil
+}
+
+// Name implements source.Source
+func (p *PDF) Name() string { return p.config.Name }
+
+// State implements source.Source
+func (p *PDF) State() string { return p.config.State }
+
+// Query implements source.Querier. The list gives the street on its own,
+// so the query is built from the street and the city heading.
+func (p *PDF) Query(shop source.Shop) geocode.Query {
+	// The street loses suite numbers and shopping center names, which
+	// confuse the geocoders
+	return geocode.Query{
+		Street:  geocode.CleanStreet(shop.Address),
+		City:    shop.City,
+		State:   p.config.State,
+		Country: "USA",
+	}
+}
+
+// Fetch downloads the PDF if there's no local copy and parses the shops
+func (p *PDF) Fetch(ctx context.Context) ([]source.Shop, error) {
+	path, err := source.Local(ctx, p.config)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Parsing quilt shops from PDF...")
+	return p.parser.parseQuiltShopsPDF(path, p.backend)
+}
+
+// parseQuiltShopsPDF reads the PDF at path and parses the shops. The city
+// headings and shop names are found by their fonts, which only the built in
+// reader knows, so it reads the PDF even with the pdftotext backend, which
+// supplies the text. A PDF without distinct heading styles is an error.
+func (p *parser) parseQuiltShopsPDF(path, backend string) ([]source.Shop, error) {
+	lines, err := readPDFLayout(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var shops []source.Shop
+	if backend == backendPdftotext {
+		text, err := extractTextPdftotext(path)
+		if err != nil {
+			return nil, err
+		}
+		shops, err = p.parseShopsFromText(text, lines)
+	} else {
+		shops, err = p.parseShopsFromLayout(lines)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't find the city headings and shop names in %s: %w", path, err)
+	}
+	return shops, nil
+}
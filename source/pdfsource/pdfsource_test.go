@@ -0,0 +1,40 @@
+package pdfsource
+
+import (
+	"testing"
+
+	"github.com/chicks-net/quilt-shop-proximity/source"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  source.Config
+		wantErr bool
+	}{
+		{"defaults", source.Config{Name: "vcq", State: "VA", Path: "vcq.pdf"}, false},
+		{"pdftotext", source.Config{Name: "vcq", State: "VA", Path: "vcq.pdf", Options: map[string]string{"backend": "pdftotext"}}, false},
+		{"no path", source.Config{Name: "vcq", State: "VA", URL: "https://example.com/vcq.pdf"}, true},
+		{"unknown backend", source.Config{Name: "vcq", State: "VA", Path: "vcq.pdf", Options: map[string]string{"backend": "ocr"}}, true},
+		{"unknown option", source.Config{Name: "vcq", State: "VA", Path: "vcq.pdf", Options: map[string]string{"skip": "x"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParserState(t *testing.T) {
+	p := newParser("MD", nil)
+	if !p.cityStateZip.MatchString("Ellicott City, MD 21043") {
+		t.Error("Maryland parser doesn't match a Maryland city line")
+	}
+	if p.cityStateZip.MatchString("Charlottesville, VA 22903") {
+		t.Error("Maryland parser matches a Virginia city line")
+	}
+}
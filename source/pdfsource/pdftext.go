@@ -0,0 +1,156 @@
+package pdfsource
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDF text backends for the backend option
+const (
+	backendGo        = "go"
+	backendPdftotext = "pdftotext"
+)
+
+// extractTextPdftotext runs the pdftotext command line tool from poppler
+func extractTextPdftotext(path string) (string, error) {
+	cmd := exec.Command("pdftotext", path, "-")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run pdftotext: %w (make sure pdftotext is installed, or use the %s backend)", err, backendGo)
+	}
+	return out.String(), nil
+}
+
+// textLine is a line of a PDF page with the style and position of its text
+type textLine struct {
+	Text string
+	Page int
+	// X and Y are where the line starts, in points from the bottom left
+	X, Y float64
+	// Size is the font size most of the line is in, to the nearest half
+	// point, and Bold whether most of it is in a bold font
+	Size float64
+	Bold bool
+}
+
+// readPDFLayout reads the lines of every page of the PDF with their style
+func readPDFLayout(path string) (lines []textLine, err error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	// The library panics on content it can't parse
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("failed to read PDF: %v", p)
+		}
+	}()
+
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, line := range textLines(page.Content().Text) {
+			line.Page = i
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// textLines joins the characters of a page into lines. The characters stay
+// in the order the PDF draws them, which is the reading order for word
+// processor exports like the VCQ list; a line ends when the baseline moves
+// or the text jumps back to the left. A gap wider than a fifth of the font
+// size between characters becomes a space.
+func textLines(chars []pdf.Text) []textLine {
+	var lines []textLine
+	start := 0
+	flush := func(end int) {
+		if line := styledLine(chars[start:end]); line.Text != "" {
+			lines = append(lines, line)
+		}
+		start = end
+	}
+
+	for i := 1; i < len(chars); i++ {
+		c, prev := chars[i], chars[i-1]
+		size := math.Max(math.Max(c.FontSize, prev.FontSize), 1)
+		gap := c.X - (prev.X + charWidth(prev))
+		if math.Abs(c.Y-prev.Y) > size/2 || gap < -size {
+			flush(i)
+		}
+	}
+	if len(chars) > 0 {
+		flush(len(chars))
+	}
+	return lines
+}
+
+// styledLine joins the characters of one line and finds its style
+func styledLine(chars []pdf.Text) textLine {
+	var text strings.Builder
+	sizes := map[float64]int{}
+	bold := 0
+	for i, c := range chars {
+		if i > 0 {
+			prev := chars[i-1]
+			size := math.Max(math.Max(c.FontSize, prev.FontSize), 1)
+			if gap := c.X - (prev.X + charWidth(prev)); gap > size/5 && c.S != " " && prev.S != " " {
+				text.WriteString(" ")
+			}
+		}
+		text.WriteString(c.S)
+
+		if strings.TrimSpace(c.S) != "" {
+			sizes[math.Round(c.FontSize*2)/2]++
+			if isBoldFont(c.Font) {
+				bold++
+			}
+		}
+	}
+
+	line := textLine{Text: strings.TrimSpace(text.String())}
+	if len(chars) > 0 {
+		line.X, line.Y = chars[0].X, chars[0].Y
+	}
+	counted := 0
+	for size, n := range sizes {
+		if n > sizes[line.Size] || (n == sizes[line.Size] && size > line.Size) {
+			line.Size = size
+		}
+		counted += n
+	}
+	line.Bold = bold*2 > counted
+	return line
+}
+
+// charWidth is how wide a character is drawn. Fonts without a widths table
+// report no width, so guess half the font size.
+func charWidth(c pdf.Text) float64 {
+	if c.W > 0 {
+		return c.W
+	}
+	return math.Max(c.FontSize, 1) / 2
+}
+
+// isBoldFont guesses from a font name like "Arial-BoldMT" whether it is bold
+func isBoldFont(name string) bool {
+	name = strings.ToLower(name)
+	for _, weight := range []string{"bold", "black", "heavy", "demi"} {
+		if strings.Contains(name, weight) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package pdfsource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfLine is a line of text drawn at a position on a test PDF page
+type pdfLine struct {
+	X, Y, Size float64
+	Text       string
+	Bold       bool
+}
+
+// writeTestPDF writes a minimal PDF with one page per entry of pages, in
+// Helvetica and Helvetica-Bold with every character 500 units wide
+func writeTestPDF(t *testing.T, pages [][]pdfLine) string {
+	t.Helper()
+
+	widths := strings.TrimSpace(strings.Repeat("500 ", 126-32+1))
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // the page tree, filled in below
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>",
+	}
+	var kids []string
+	for _, lines := range pages {
+		var content strings.Builder
+		for _, l := range lines {
+			text := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(l.Text)
+			font := "F1"
+			if l.Bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, l.Size, l.X, l.Y, text)
+		}
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", len(objects)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var out strings.Builder
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, []byte(out.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadPDFLayout(t *testing.T) {
+	path := writeTestPDF(t, [][]pdfLine{
+		{
+			{72, 720, 16, "Quilt Shops", false},
+			{72, 690, 12, "Charlottesville", false},
+			{72, 675, 10, "Les Fabriques", false},
+			{72, 662, 10, "1135 Sunset Ave", false},
+			// Drawn as two runs on one baseline, like a tab stop
+			{72, 649, 10, "Charlottesville, VA", false},
+			{180, 649, 10, "22903", false},
+			{72, 636, 10, "(434) 555-0100", false},
+		},
+		{
+			{72, 720, 12, "Richmond", false},
+			{72, 705, 10, "Quilting Adventures", false},
+		},
+	})
+
+	lines, err := readPDFLayout(path)
+	if err != nil {
+		t.Fatalf("readPDFLayout() error = %v", err)
+	}
+	var got []string
+	for _, line := range lines {
+		got = append(got, fmt.Sprintf("%d %s", line.Page, line.Text))
+	}
+	want := []string{"1 Quilt Shops", "1 Charlottesville", "1 Les Fabriques", "1 1135 Sunset Ave",
+		"1 Charlottesville, VA 22903", "1 (434) 555-0100", "2 Richmond", "2 Quilting Adventures"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("readPDFLayout() = %q, want %q", got, want)
+	}
+
+}
+
+func TestParseQuiltShopsPDFNoHeadings(t *testing.T) {
+	// Everything in one style, so nothing tells cities from shops
+	path := writeTestPDF(t, [][]pdfLine{{
+		{72, 690, 10, "Charlottesville", false},
+		{72, 675, 10, "Les Fabriques", false},
+		{72, 662, 10, "1135 Sunset Ave", false},
+		{72, 649, 10, "Charlottesville, VA 22903", false},
+	}})
+	if _, err := testParser.parseQuiltShopsPDF(path, backendGo); err == nil {
+		t.Error("parseQuiltShopsPDF() error = nil, want an error for a PDF without heading styles")
+	}
+}
+
+func TestReadPDFLayoutMissingFile(t *testing.T) {
+	if _, err := readPDFLayout(filepath.Join(t.TempDir(), "missing.pdf")); err == nil {
+		t.Error("readPDFLayout() error = nil, want an error for a missing file")
+	}
+}
+
+func TestTextLines(t *testing.T) {
+	// chars lays out s from x one character per 5 points at size 10
+	chars := func(s string, x, y float64) []pdf.Text {
+		var out []pdf.Text
+		for _, r := range s {
+			out = append(out, pdf.Text{FontSize: 10, X: x, Y: y, W: 5, S: string(r)})
+			x += 5
+		}
+		return out
+	}
+	join := func(parts ...[]pdf.Text) []pdf.Text {
+		var out []pdf.Text
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		chars []pdf.Text
+		want  []string
+	}{
+		{"one line", chars("Sew Fun", 0, 100), []string{"Sew Fun"}},
+		{"baseline moves", join(chars("Name", 0, 100), chars("Street", 0, 88)), []string{"Name", "Street"}},
+		{"small jitter stays on the line", join(chars("Sew", 0, 100), chars("Fun", 20, 101)), []string{"Sew Fun"}},
+		{"gap becomes a space", join(chars("Richmond,", 0, 100), chars("VA", 60, 100)), []string{"Richmond, VA"}},
+		{"kerning is not a space", join(chars("Qu", 0, 100), chars("ilt", 11, 100)), []string{"Quilt"}},
+		{"jump back left on the same baseline", join(chars("Left column", 0, 100), chars("Right", 0, 100)), []string{"Left column", "Right"}},
+		{"no widths", []pdf.Text{
+			{FontSize: 10, X: 0, Y: 0, S: "V"},
+			{FontSize: 10, X: 6, Y: 0, S: "A"},
+			{FontSize: 10, X: 20, Y: 0, S: "2"},
+		}, []string{"VA 2"}},
+		{"empty", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range textLines(tt.chars) {
+				got = append(got, line.Text)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("textLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package source is the pipeline shared by the state scrapers. Each kind of
+// quilt shop list is a source type that registers itself; sources of that
+// type are declared in the config file, and the pipeline stores what they
+// fetch in SQLite, geocodes the shops and checks the coordinates.
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+)
+
+// Shop is a quilt shop as a source lists it
+type Shop struct {
+	Name    string
+	Address string
+	City    string
+	Phone   string
+	Email   string
+	Website string
+}
+
+// Source is a published list of the quilt shops in one state
+type Source interface {
+	// Name is the short name the source is declared under, like "vcq"
+	Name() string
+	// State is the two letter code of the state the list covers
+	State() string
+	// Fetch downloads and parses the list
+	Fetch(ctx context.Context) ([]Shop, error)
+}
+
+// Querier is implemented by sources that know better than a plain address
+// line how to ask a geocoder for one of their shops
+type Querier interface {
+	Query(shop Shop) geocode.Query
+}
+
+// Config declares a source: the type of list it is, where to get it and
+// the options for the type's parser
+type Config struct {
+	Name  string `toml:"name"`
+	Type  string `toml:"type"`
+	State string `toml:"state"`
+	// URL is where the list is published
+	URL string `toml:"url"`
+	// Path is a local copy of the list, used instead of URL when it exists
+	// and kept there after a download
+	Path string `toml:"path"`
+	// Database is the state database the shops go in
+	Database string            `toml:"database"`
+	Options  map[string]string `toml:"options"`
+}
+
+// Option returns the named parser option, or def if it isn't set
+func (c Config) Option(key, def string) string {
+	if v, ok := c.Options[key]; ok {
+		return v
+	}
+	return def
+}
+
+// CheckOptions returns an error naming the first option that isn't one of
+// known, so a typo in the config doesn't go unnoticed
+func (c Config) CheckOptions(known ...string) error {
+	keys := make([]string, 0, len(c.Options))
+	for key := range c.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		found := false
+		for _, k := range known {
+			found = found || k == key
+		}
+		if !found {
+			return fmt.Errorf("source %s: unknown %s option %q (choose from %s)", c.Name, c.Type, key, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// Factory builds a source of one type from its config
+type Factory func(Config) (Source, error)
+
+// registry maps source types to their factories
+var registry = map[string]Factory{}
+
+// Register makes a source type available by name. It is meant to be called
+// from the init function of the type's package and panics if the name is
+// taken.
+func Register(typ string, f Factory) {
+	typ = strings.ToLower(typ)
+	if _, dup := registry[typ]; dup {
+		panic("source: Register called twice for " + typ)
+	}
+	registry[typ] = f
+}
+
+// Types returns the names of the registered source types
+func Types() []string {
+	types := make([]string, 0, len(registry))
+	for typ := range registry {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New builds the source a config declares
+func New(c Config) (Source, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("source has no name")
+	}
+	state := geocode.StateCode(c.State)
+	if state == "" {
+		return nil, fmt.Errorf("source %s: unknown state %q", c.Name, c.State)
+	}
+	c.State = state
+
+	f, ok := registry[strings.ToLower(strings.TrimSpace(c.Type))]
+	if !ok {
+		return nil, fmt.Errorf("source %s: unknown type %q (choose from %s)", c.Name, c.Type, strings.Join(Types(), ", "))
+	}
+	return f(c)
+}
+
+// QueryFor returns the geocoding query for a shop: the source's own, if it
+// is a Querier, or otherwise the shop's address split into fields
+func QueryFor(s Source, shop Shop) geocode.Query {
+	if q, ok := s.(Querier); ok {
+		return q.Query(shop)
+	}
+	return geocode.ParseAddressLine(shop.Address)
+}
@@ -0,0 +1,313 @@
+package source
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+)
+
+// testSource is a list with fixed shops whose addresses are street only
+type testSource struct {
+	name  string
+	shops []Shop
+}
+
+func (s testSource) Name() string                              { return s.name }
+func (s testSource) State() string                             { return "VA" }
+func (s testSource) Fetch(ctx context.Context) ([]Shop, error) { return s.shops, nil }
+
+// streetSource builds its queries from the street and the city
+type streetSource struct{ testSource }
+
+func (streetSource) Query(shop Shop) geocode.Query {
+	return geocode.Query{Street: shop.Address, City: shop.City, State: "VA"}
+}
+
+// fakeGeocoder finds the streets it knows and records every query
+type fakeGeocoder struct {
+	known   map[string]geocode.Coordinates
+	queries []geocode.Query
+}
+
+func (f *fakeGeocoder) Geocode(ctx context.Context, query geocode.Query) (geocode.Result, error) {
+	f.queries = append(f.queries, query)
+	coords, ok := f.known[query.Street]
+	if !ok {
+		return geocode.Result{}, geocode.ErrNoResults
+	}
+	return geocode.Result{Coords: &coords, Provider: "fake", MatchType: geocode.MatchRooftop}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	defer func(saved map[string]Factory) { registry = saved }(registry)
+	registry = map[string]Factory{}
+
+	factory := func(c Config) (Source, error) { return testSource{name: c.Name}, nil }
+	Register("B-Type", factory)
+	Register("a-type", factory)
+
+	if got := Types(); len(got) != 2 || got[0] != "a-type" || got[1] != "b-type" {
+		t.Errorf("Types() = %v, want [a-type b-type]", got)
+	}
+	if s, err := New(Config{Name: "list", Type: " B-TYPE ", State: "Virginia"}); err != nil || s.Name() != "list" {
+		t.Errorf("New() = %v, %v, want list", s, err)
+	}
+
+	bad := []Config{
+		{Type: "a-type", State: "VA"},
+		{Name: "list", Type: "c-type", State: "VA"},
+		{Name: "list", Type: "a-type", State: "Atlantis"},
+	}
+	for _, c := range bad {
+		if _, err := New(c); err == nil {
+			t.Errorf("New(%+v) error = nil, want an error", c)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of a taken type didn't panic")
+		}
+	}()
+	Register("a-type", factory)
+}
+
+func TestConfigOptions(t *testing.T) {
+	c := Config{Name: "list", Type: "html", Options: map[string]string{"city_selector": "h2", "name_selector": ""}}
+
+	if got := c.Option("city_selector", "h3"); got != "h2" {
+		t.Errorf("Option() = %q, want h2", got)
+	}
+	if got := c.Option("name_selector", "strong"); got != "" {
+		t.Errorf("Option() = %q, want the empty value that's set", got)
+	}
+	if got := c.Option("entry_selector", "pre"); got != "pre" {
+		t.Errorf("Option() = %q, want the default", got)
+	}
+
+	if err := c.CheckOptions("city_selector", "entry_selector", "name_selector"); err != nil {
+		t.Errorf("CheckOptions() error = %v", err)
+	}
+	if err := c.CheckOptions("city_selector"); err == nil {
+		t.Error("CheckOptions() error = nil, want an unknown option error")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/list.pdf" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "the list")
+	}))
+	defer server.Close()
+
+	c := Config{Name: "list", URL: server.URL + "/list.pdf", Path: filepath.Join(t.TempDir(), "lists", "list.pdf")}
+	for i := 0; i < 2; i++ {
+		path, err := Local(context.Background(), c)
+		if err != nil {
+			t.Fatalf("Local() error = %v", err)
+		}
+		if data, err := os.ReadFile(path); err != nil || string(data) != "the list" {
+			t.Errorf("Local() copy = %q, %v, want the list", data, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Local() made %d requests, want 1 with the copy kept", requests)
+	}
+
+	c.URL, c.Path = server.URL+"/missing.pdf", filepath.Join(t.TempDir(), "missing.pdf")
+	if _, err := Local(context.Background(), c); err == nil {
+		t.Error("Local() error = nil, want a status code error")
+	}
+	if _, err := os.Stat(c.Path); !os.IsNotExist(err) {
+		t.Errorf("failed download left %s behind", c.Path)
+	}
+}
+
+func TestQueryFor(t *testing.T) {
+	shop := Shop{Address: "1135 Sunset Ave, Charlottesville, VA 22903", City: "Charlottesville"}
+
+	q := QueryFor(testSource{}, shop)
+	if q.Street != "1135 Sunset Ave" || q.City != "Charlottesville" || q.State != "VA" || q.PostalCode != "22903" {
+		t.Errorf("QueryFor() = %+v, want the address line split into fields", q)
+	}
+
+	shop.Address = "1135 Sunset Ave"
+	q = QueryFor(streetSource{}, shop)
+	if q.Street != "1135 Sunset Ave" || q.City != "Charlottesville" || q.State != "VA" {
+		t.Errorf("QueryFor() = %+v, want the source's own query", q)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	src := streetSource{testSource{name: "test", shops: []Shop{
+		{Name: "Les Fabriques", Address: "1135 Sunset Ave", City: "Charlottesville", Website: "www.lesfabriques.example"},
+		{Name: "Lost Shop", Address: "1 Nowhere Rd", City: "Richmond"},
+		{Name: "No Address", City: "Norfolk"},
+	}}}
+	path := filepath.Join(t.TempDir(), "quilt_shops.db")
+
+	shops, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Source: "test", Type: "list", State: "VA", URL: "https://example.com/list", FetchedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	if err := CreateDatabase(path, info, shops); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+
+	geocoder := &fakeGeocoder{known: map[string]geocode.Coordinates{
+		"1135 Sunset Ave": {Latitude: 38.0293, Longitude: -78.4767},
+	}}
+	if err := GeocodeShops(context.Background(), path, src, geocoder, nil); err != nil {
+		t.Fatalf("GeocodeShops() error = %v", err)
+	}
+	if len(geocoder.queries) != 2 || geocoder.queries[0].City != "Charlottesville" {
+		t.Errorf("geocoder got queries %+v, want the two shops with addresses", geocoder.queries)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	recorded := map[string]string{}
+	infoRows, err := db.Query("SELECT key, value FROM metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for infoRows.Next() {
+		var key, value string
+		if err := infoRows.Scan(&key, &value); err != nil {
+			t.Fatal(err)
+		}
+		recorded[key] = value
+	}
+	infoRows.Close()
+	wantInfo := map[string]string{"source": "test", "type": "list", "state": "VA", "url": "https://example.com/list", "fetched_at": "2025-03-01T12:00:00Z"}
+	if !reflect.DeepEqual(recorded, wantInfo) {
+		t.Errorf("metadata = %v, want %v", recorded, wantInfo)
+	}
+
+	rows, err := db.Query("SELECT name, website, latitude, geocode_attempted_at IS NOT NULL FROM quilt_shops ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	type row struct {
+		name, website string
+		latitude      sql.NullFloat64
+		attempted     bool
+	}
+	want := []row{
+		{"Les Fabriques", "www.lesfabriques.example", sql.NullFloat64{Float64: 38.0293, Valid: true}, true},
+		{"Lost Shop", "", sql.NullFloat64{}, true},
+		{"No Address", "", sql.NullFloat64{}, true},
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.name, &r.website, &r.latitude, &r.attempted); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("found %d shops, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("shop %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCreateDatabaseUpdatesShops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quilt_shops.db")
+	info := Info{Source: "test", Type: "list", State: "VA"}
+	first := []Shop{
+		{Name: "Les Fabriques", Address: "224 W Main St", City: "Charlottesville"},
+		{Name: "Sew Classic", Address: "1 Main St", City: "Mount Crawford"},
+		{Name: "Closed Quilts", Address: "2 Elm St", City: "Staunton"},
+	}
+	if err := CreateDatabase(path, info, first); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// Geocode every shop, with a rejected match each
+	if _, err := db.Exec(`UPDATE quilt_shops SET latitude = 38, longitude = -78`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO geocode_rejections (shop_id, query, reason) SELECT id, name, 'in KY' FROM quilt_shops`); err != nil {
+		t.Fatal(err)
+	}
+
+	second := []Shop{
+		{Name: "Les Fabriques", Address: "224 W Main St", City: "Charlottesville", Phone: "434-555-0100"},
+		{Name: "sew classic", Address: "9 Port Rd", City: "Mount Crawford"},
+		{Name: "Bits & Pieces", Address: "3 Oak St", City: "Lexington"},
+	}
+	if err := CreateDatabase(path, info, second); err != nil {
+		t.Fatalf("CreateDatabase() again error = %v", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT s.id, s.name, COALESCE(s.phone, ''), s.latitude IS NOT NULL,
+			(SELECT COUNT(*) FROM geocode_rejections r WHERE r.shop_id = s.id)
+		FROM quilt_shops s ORDER BY s.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var (
+			id, rejections int
+			name, phone    string
+			geocoded       bool
+		)
+		if err := rows.Scan(&id, &name, &phone, &geocoded, &rejections); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d %s %q %v %d", id, name, phone, geocoded, rejections))
+	}
+	want := []string{
+		// Unchanged address: same id, still geocoded
+		`1 Les Fabriques "434-555-0100" true 1`,
+		// Moved: geocoding and rejections cleared
+		`2 sew classic "" false 0`,
+		// Closed Quilts is gone and Bits & Pieces is new
+		`4 Bits & Pieces "" false 0`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("shops after scraping again = %q, want %q", got, want)
+	}
+
+	var orphans int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM geocode_rejections WHERE shop_id NOT IN (SELECT id FROM quilt_shops)`).Scan(&orphans); err != nil {
+		t.Fatal(err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d rejected matches left for removed shops", orphans)
+	}
+}
@@ -0,0 +1,257 @@
+package source
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chicks-net/quilt-shop-proximity/geocode"
+	_ "modernc.org/sqlite"
+)
+
+// Info describes where a state database's shops came from. CreateDatabase
+// records it in the database's metadata table, where merge reads the state.
+type Info struct {
+	Source string
+	Type   string
+	State  string
+	URL    string
+	// FetchedAt is when the list was fetched; zero means now
+	FetchedAt time.Time
+}
+
+// CreateDatabase creates the SQLite database at path and populates it with
+// shop data. Scraping again updates the shops already there, matched by
+// name and city, so they keep their ids and geocoding unless their address
+// changed; shops no longer on the list are removed.
+func CreateDatabase(path string, info Info, shops []Shop) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// Create table
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS quilt_shops (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		address TEXT,
+		city TEXT NOT NULL,
+		phone TEXT,
+		email TEXT,
+		website TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_city ON quilt_shops(city);
+	CREATE INDEX IF NOT EXISTS idx_name ON quilt_shops(name);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+	// The geocoding columns, so a changed address can clear them
+	addGeocodeColumns(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := updateShops(tx, shops); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save shops: %w", err)
+	}
+
+	return writeInfo(db, info)
+}
+
+// storedShop is a shop from an earlier scrape
+type storedShop struct {
+	id      int
+	address string
+}
+
+// shopKey matches a scraped shop to the stored one, ignoring case and
+// surrounding space
+func shopKey(name, city string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "|" + strings.ToLower(strings.TrimSpace(city))
+}
+
+// updateShops brings the stored shops in line with a new scrape: updating
+// the ones still listed, adding new ones and removing the rest. A shop
+// whose address changed loses its coordinates and rejected matches, so the
+// next geocoding run places it again.
+func updateShops(tx *sql.Tx, shops []Shop) error {
+	rows, err := tx.Query(`SELECT id, name, city, COALESCE(address, '') FROM quilt_shops ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query shops: %w", err)
+	}
+	stored := map[string][]storedShop{}
+	for rows.Next() {
+		var s storedShop
+		var name, city string
+		if err := rows.Scan(&s.id, &name, &city, &s.address); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan shop: %w", err)
+		}
+		key := shopKey(name, city)
+		stored[key] = append(stored[key], s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query shops: %w", err)
+	}
+
+	for _, shop := range shops {
+		key := shopKey(shop.Name, shop.City)
+		if len(stored[key]) == 0 {
+			_, err := tx.Exec(`INSERT INTO quilt_shops (name, address, city, phone, email, website) VALUES (?, ?, ?, ?, ?, ?)`,
+				shop.Name, shop.Address, shop.City, shop.Phone, shop.Email, shop.Website)
+			if err != nil {
+				log.Printf("Warning: failed to insert shop %s: %v", shop.Name, err)
+			}
+			continue
+		}
+
+		// Shops with the same name in the same city pair up in order
+		old := stored[key][0]
+		stored[key] = stored[key][1:]
+		_, err := tx.Exec(`UPDATE quilt_shops SET name = ?, address = ?, city = ?, phone = ?, email = ?, website = ? WHERE id = ?`,
+			shop.Name, shop.Address, shop.City, shop.Phone, shop.Email, shop.Website, old.id)
+		if err != nil {
+			return fmt.Errorf("failed to update shop %s: %w", shop.Name, err)
+		}
+		if old.address != shop.Address {
+			if err := clearGeocode(tx, old.id); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Whatever wasn't paired up is no longer listed
+	for _, gone := range stored {
+		for _, s := range gone {
+			if _, err := tx.Exec(`DELETE FROM geocode_rejections WHERE shop_id = ?`, s.id); err != nil {
+				return fmt.Errorf("failed to remove rejected matches: %w", err)
+			}
+			if _, err := tx.Exec(`DELETE FROM quilt_shops WHERE id = ?`, s.id); err != nil {
+				return fmt.Errorf("failed to remove shop: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// clearGeocode forgets where a shop was placed and the matches rejected for
+// it
+func clearGeocode(tx *sql.Tx, id int) error {
+	_, err := tx.Exec(`
+		UPDATE quilt_shops
+		SET latitude = NULL, longitude = NULL, geocode_attempted_at = NULL,
+			geocode_provider = NULL, geocode_match_type = NULL, geocode_confidence = NULL,
+			geocode_display_name = NULL, geocode_class = NULL, geocode_type = NULL,
+			geocode_county = NULL, geocode_postcode = NULL, geocode_tiger_line_id = NULL,
+			geocode_warning = NULL
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to clear geocoding: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM geocode_rejections WHERE shop_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to remove rejected matches: %w", err)
+	}
+	return nil
+}
+
+// writeInfo records where the shops came from in the metadata table, which
+// has the same key and value layout as the merged database's
+func writeInfo(db *sql.DB, info Info) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS metadata (
+			key TEXT PRIMARY KEY,
+			value TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata table: %w", err)
+	}
+
+	if info.FetchedAt.IsZero() {
+		info.FetchedAt = time.Now()
+	}
+	values := [][2]string{
+		{"source", info.Source},
+		{"type", info.Type},
+		{"state", info.State},
+		{"url", info.URL},
+		{"fetched_at", info.FetchedAt.UTC().Format(time.RFC3339)},
+	}
+	for _, kv := range values {
+		_, err := db.Exec(`INSERT OR REPLACE INTO metadata (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)`, kv[0], kv[1])
+		if err != nil {
+			return fmt.Errorf("failed to record %s: %w", kv[0], err)
+		}
+	}
+	return nil
+}
+
+// addGeocodeColumns applies the schema migration for geocoding results
+func addGeocodeColumns(db *sql.DB) {
+	// SQLite doesn't support IF NOT EXISTS with ALTER TABLE, so we try to add columns
+	// and ignore errors if they already exist
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN latitude REAL")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN longitude REAL")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_attempted_at DATETIME")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_provider TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_match_type TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_confidence REAL")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_display_name TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_class TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_type TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_county TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_postcode TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_tiger_line_id TEXT")
+	db.Exec("ALTER TABLE quilt_shops ADD COLUMN geocode_warning TEXT")
+
+	// Candidates skipped for being in the wrong place, kept for review
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS geocode_rejections (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			shop_id INTEGER NOT NULL,
+			query TEXT NOT NULL,
+			provider TEXT,
+			latitude REAL,
+			longitude REAL,
+			display_name TEXT,
+			reason TEXT NOT NULL,
+			rejected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+}
+
+// recordRejections saves the candidates the geocoder passed over for a shop
+func recordRejections(db *sql.DB, shopID int, query geocode.Query, rejected []geocode.Rejection) {
+	for _, r := range rejected {
+		var lat, lon sql.NullFloat64
+		if r.Candidate.Coords != nil {
+			lat = sql.NullFloat64{Float64: r.Candidate.Coords.Latitude, Valid: true}
+			lon = sql.NullFloat64{Float64: r.Candidate.Coords.Longitude, Valid: true}
+		}
+		_, err := db.Exec(`
+			INSERT INTO geocode_rejections (shop_id, query, provider, latitude, longitude, display_name, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, shopID, query.String(), r.Candidate.Provider, lat, lon, r.Candidate.DisplayName, r.Reason)
+		if err != nil {
+			log.Printf("       Warning: failed to record rejected match: %v", err)
+			continue
+		}
+		log.Printf("       ⚠ Rejected %s (%s)", r.Candidate.DisplayName, r.Reason)
+	}
+}
@@ -21,12 +21,22 @@ func test_database_queries():
 	print("Shops by state: ", db.query_result)
 	
 	# Test Query 3: Get a few sample shops with coordinates
-	db.query("SELECT name, city, state, latitude, longitude FROM quilt_shops LIMIT 5;")
+	# approximate is 1 for shops pinned at the middle of their town or ZIP code
+	db.query("SELECT name, city, state, latitude, longitude, COALESCE(geocode_match_type IN ('locality', 'region'), 0) AS approximate FROM quilt_shops LIMIT 5;")
 	print("Sample shops: ", db.query_result)
 	
 	# Test Query 4: Get shops in a specific city (e.g., Berkeley)
 	db.query("SELECT name, address, phone, website FROM quilt_shops WHERE city = 'Berkeley';")
 	print("Berkeley shops: ", db.query_result)
 	
+	# Test Query 5: Read what the database holds and how it was built
+	db.query("SELECT key, value FROM metadata;")
+	var metadata = {}
+	for row in db.query_result:
+		metadata[row["key"]] = row["value"]
+	print("Data version: ", metadata.get("version"), " (schema ", metadata.get("schema_version"), ") built ", metadata.get("built_at"), " from commit ", metadata.get("git_commit"))
+	print("Shops by state (metadata): ", JSON.parse_string(metadata.get("states", "{}")))
+	print("Sources: ", JSON.parse_string(metadata.get("sources", "[]")))
+	
 	db.close_db()
 	print("Database tests completed!")